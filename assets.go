@@ -0,0 +1,267 @@
+package vast2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultAssetConcurrency        = 8
+	DefaultAssetConcurrencyPerHost = 2
+)
+
+var (
+	ErrAssetType  = errors.New("vast2: asset Content-Type does not match the declared type")
+	ErrAssetSize  = errors.New("vast2: asset is too large")
+	ErrAssetEmpty = errors.New("vast2: asset is empty")
+)
+
+// AssetChecker checks that the assets of a document can be downloaded.
+type AssetChecker struct {
+	// Client is used for the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Concurrency is the number of assets checked at once,
+	// DefaultAssetConcurrency if 0.
+	Concurrency int
+	// ConcurrencyPerHost is the number of assets checked at once on a
+	// host, DefaultAssetConcurrencyPerHost if 0.
+	ConcurrencyPerHost int
+	// MaxMediaFileSize and MaxResourceSize are the sizes in bytes above
+	// which MediaFiles and StaticResources are rejected. 0 means no limit.
+	MaxMediaFileSize int64
+	MaxResourceSize  int64
+}
+
+// AssetResult is the outcome of the check of an asset.
+type AssetResult struct {
+	Path Path
+	Kind URLKind
+	URL  string
+	// DeclaredType is the MediaFile type or StaticResource creativeType.
+	DeclaredType string
+	// Method is the method of the last request, HEAD or GET.
+	Method      string
+	StatusCode  int
+	ContentType string
+	// Size is the size of the asset in bytes, -1 if unknown.
+	Size int64
+	// Err is nil if the asset passed the check.
+	Err error
+}
+
+// asset is an URL of a document to check.
+type asset struct {
+	path         Path
+	kind         URLKind
+	url          string
+	declaredType string
+}
+
+func (c *AssetChecker) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+// Check requests every MediaFile, StaticResource and IFrameResource of v,
+// and the CompanionClickThrough URLs, and returns the results in document
+// order. An asset passes if it responds with a 2xx status and, for
+// MediaFiles and StaticResources, with the declared Content-Type and a size
+// within the limits. A HEAD request is sent first, then a GET request for
+// the first byte if the server does not support HEAD.
+func (c *AssetChecker) Check(ctx context.Context, v *VAST) []AssetResult {
+	assets := collectAssets(v)
+	results := make([]AssetResult, len(assets))
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultAssetConcurrency
+	}
+	perHost := c.ConcurrencyPerHost
+	if perHost <= 0 {
+		perHost = DefaultAssetConcurrencyPerHost
+	}
+	var mu sync.Mutex
+	hosts := map[string]chan struct{}{}
+	hostSem := func(host string) chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+		sem, ok := hosts[host]
+		if !ok {
+			sem = make(chan struct{}, perHost)
+			hosts[host] = sem
+		}
+		return sem
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < concurrency && n < len(assets); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				a := assets[i]
+				sem := hostSem(assetHost(a.url))
+				sem <- struct{}{}
+				results[i] = c.check(ctx, a)
+				<-sem
+			}
+		}()
+	}
+	for i := range assets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// collectAssets returns the URLs to check with the type declared for them.
+func collectAssets(v *VAST) []asset {
+	var assets []asset
+	var mediaType string
+	var companion *Companion
+	var nonLinear *NonLinear
+	Walk(v, Visitor{
+		MediaFile: func(p Path, m *MediaFile) WalkAction {
+			mediaType = m.Type
+			return WalkContinue
+		},
+		Companion: func(p Path, c *Companion) WalkAction {
+			companion = c
+			return WalkContinue
+		},
+		NonLinear: func(p Path, n *NonLinear) WalkAction {
+			nonLinear = n
+			return WalkContinue
+		},
+		URL: func(p Path, kind URLKind, url *string) {
+			a := asset{path: p, kind: kind, url: strings.TrimSpace(*url)}
+			switch kind {
+			case URLMediaFile:
+				a.declaredType = mediaType
+			case URLStaticResource:
+				// The StaticResource belongs to the Companion or
+				// NonLinear visited last, per the kind of its parent.
+				if len(p) >= 2 && p[len(p)-2].Name == "Companion" {
+					a.declaredType = companion.StaticResource.CreativeType
+				} else {
+					a.declaredType = nonLinear.StaticResource.CreativeType
+				}
+			case URLIFrameResource, URLCompanionClickThrough:
+			default:
+				return
+			}
+			a.declaredType = strings.TrimSpace(a.declaredType)
+			assets = append(assets, a)
+		},
+	})
+	return assets
+}
+
+func assetHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func (c *AssetChecker) check(ctx context.Context, a asset) AssetResult {
+	res := AssetResult{Path: a.path, Kind: a.kind, URL: a.url, DeclaredType: a.declaredType, Size: -1}
+
+	resp, err := c.request(ctx, http.MethodHead, a.url)
+	res.Method = http.MethodHead
+	if err != nil || resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		resp, err = c.request(ctx, http.MethodGet, a.url)
+		res.Method = http.MethodGet
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.StatusCode = resp.StatusCode
+	res.ContentType = resp.Header.Get("Content-Type")
+	res.Size = responseSize(resp)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		res.Err = &StatusError{URL: a.url, StatusCode: resp.StatusCode}
+		return res
+	}
+
+	var maxSize int64
+	switch a.kind {
+	case URLMediaFile:
+		maxSize = c.MaxMediaFileSize
+	case URLStaticResource:
+		maxSize = c.MaxResourceSize
+	default:
+		return res
+	}
+	switch {
+	case a.declaredType != "" && !sameMediaType(a.declaredType, res.ContentType):
+		res.Err = fmt.Errorf("%w: %q, declared %q", ErrAssetType, res.ContentType, a.declaredType)
+	case res.Size == 0:
+		res.Err = ErrAssetEmpty
+	case maxSize > 0 && res.Size > maxSize:
+		res.Err = fmt.Errorf("%w: %d bytes, limit %d", ErrAssetSize, res.Size, maxSize)
+	}
+	return res
+}
+
+// request sends a HEAD request, or a GET request for the first byte, and
+// discards the body.
+func (c *AssetChecker) request(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// A server ignoring the range sends the whole asset: stop reading it.
+	io.CopyN(io.Discard, resp.Body, 1)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// responseSize returns the size of the asset from the Content-Range of a
+// partial response or the Content-Length of a full one, -1 if unknown.
+func responseSize(resp *http.Response) int64 {
+	if resp.StatusCode == http.StatusPartialContent {
+		cr := resp.Header.Get("Content-Range")
+		if i := strings.LastIndexByte(cr, '/'); i >= 0 {
+			if size, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return size
+			}
+		}
+		return -1
+	}
+	return resp.ContentLength
+}
+
+// sameMediaType compares two Content-Types, ignoring their parameters.
+func sameMediaType(a, b string) bool {
+	ta, _, err := mime.ParseMediaType(a)
+	if err != nil {
+		return false
+	}
+	tb, _, err := mime.ParseMediaType(b)
+	if err != nil {
+		return false
+	}
+	return ta == tb
+}
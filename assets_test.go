@@ -0,0 +1,148 @@
+package vast2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAssetsVAST(base string) *VAST {
+	linear := &Linear{
+		Duration: "00:00:15",
+		MediaFiles: MediaFiles{MediaFile: []MediaFile{
+			{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: base + "/video.mp4"},
+			{Delivery: "progressive", Type: "video/webm", Width: 640, Height: 360, Data: base + "/video.mp4"},
+			{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: base + "/missing.mp4"},
+			{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: base + "/large.mp4"},
+			{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: base + "/nohead.mp4"},
+		}},
+	}
+	companions := &CompanionAds{Companion: []Companion{{
+		Width:                 300,
+		Height:                250,
+		StaticResource:        &StaticResource{CreativeType: "image/png", Data: base + "/banner.png"},
+		CompanionClickThrough: base + "/click",
+	}}}
+	nonLinears := &NonLinearAds{NonLinear: []NonLinear{{
+		Width:          300,
+		Height:         50,
+		IFrameResource: base + "/iframe.html",
+		StaticResource: &StaticResource{CreativeType: "image/gif", Data: base + "/banner.png"},
+	}}}
+	return &VAST{Version: "2.0", Ad: []Ad{NewInLineAd("1", &InLine{
+		AdSystem: AdSystem{Data: "x"},
+		AdTitle:  "x",
+		Creatives: Creatives{Creative: []Creative{
+			NewLinearCreative(linear),
+			NewCompanionAdsCreative(companions),
+			NewNonLinearAdsCreative(nonLinears),
+		}},
+	})}}
+}
+
+func TestAssetCheckerCheck(t *testing.T) {
+	serve := func(typ string, size int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", typ)
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(strings.Repeat("x", size)))
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/video.mp4", serve("video/mp4", 1000))
+	mux.Handle("/large.mp4", serve("video/mp4", 5000))
+	mux.Handle("/banner.png", serve("image/png; charset=binary", 100))
+	mux.Handle("/iframe.html", serve("text/html", 100))
+	mux.Handle("/click", serve("text/html", 0))
+	mux.HandleFunc("/nohead.mp4", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		serve("video/mp4", 1000)(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checker := &AssetChecker{Client: server.Client(), MaxMediaFileSize: 4000}
+	results := checker.Check(context.Background(), testAssetsVAST(server.URL))
+
+	var got []string
+	for _, r := range results {
+		s := r.Path.String() + " " + r.Kind.String() + " " + r.Method + " " + http.StatusText(r.StatusCode)
+		switch {
+		case r.Err == nil:
+			s += " ok"
+		case errors.Is(r.Err, ErrAssetType):
+			s += " type"
+		case errors.Is(r.Err, ErrAssetSize):
+			s += " size"
+		default:
+			s += " " + r.Err.Error()
+		}
+		got = append(got, s)
+	}
+	assert.Equal(t, got, []string{
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0] MediaFile HEAD OK ok",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[1] MediaFile HEAD OK type",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[2] MediaFile HEAD Not Found vast2: " + server.URL + "/missing.mp4: unexpected status 404",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[3] MediaFile HEAD OK size",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[4] MediaFile GET Partial Content ok",
+		"Ad[0]/InLine/Creatives/Creative[1]/CompanionAds/Companion[0]/CompanionClickThrough CompanionClickThrough HEAD OK ok",
+		"Ad[0]/InLine/Creatives/Creative[1]/CompanionAds/Companion[0]/StaticResource StaticResource HEAD OK ok",
+		"Ad[0]/InLine/Creatives/Creative[2]/NonLinearAds/NonLinear[0]/IFrameResource IFrameResource HEAD OK ok",
+		"Ad[0]/InLine/Creatives/Creative[2]/NonLinearAds/NonLinear[0]/StaticResource StaticResource HEAD OK type",
+	})
+	assert.Equal(t, results[0].Size, int64(1000))
+	assert.Equal(t, results[0].DeclaredType, "video/mp4")
+	assert.Equal(t, results[4].Size, int64(1000))
+	assert.Equal(t, results[6].ContentType, "image/png; charset=binary")
+}
+
+func TestAssetCheckerConcurrencyPerHost(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", "1000")
+	}))
+	defer server.Close()
+
+	v := testAssetsVAST(server.URL)
+	checker := &AssetChecker{Client: server.Client(), ConcurrencyPerHost: 2}
+	results := checker.Check(context.Background(), v)
+	assert.Len(t, results, 9)
+	assert.Equal(t, maxActive, 2)
+}
+
+func TestAssetCheckerUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	v := testAssetsVAST(server.URL)
+	results := (&AssetChecker{}).Check(context.Background(), v)
+	assert.Len(t, results, 9)
+	for _, r := range results {
+		assert.Error(t, r.Err)
+		assert.Equal(t, r.Method, http.MethodGet)
+		assert.Equal(t, r.Size, int64(-1))
+	}
+
+	assert.Empty(t, (&AssetChecker{}).Check(context.Background(), &VAST{}))
+}
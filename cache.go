@@ -0,0 +1,202 @@
+package vast2
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the decoded responses of tag URLs for a Resolver. The
+// responses fetched on behalf of a viewer, with a ClientInfo, are stored for
+// that viewer only: their key is the URL followed by the fields of the
+// ClientInfo. The Resolver never modifies the documents it passes to Set nor those returned
+// by Get: it clones them before handing them out, so implementations can
+// store and return them as is. Implementations must be safe for concurrent
+// use.
+type Cache interface {
+	// Get returns the document stored for key if it has not expired.
+	Get(key string) (*VAST, bool)
+	// Set stores v for key until expires.
+	Set(key string, v *VAST, expires time.Time)
+}
+
+// LRUCache is an in-memory Cache holding a bounded number of documents,
+// evicting the least recently used one when full.
+type LRUCache struct {
+	size int
+	// now returns the current time, time.Now if nil.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   list.List
+}
+
+type lruEntry struct {
+	key     string
+	v       *VAST
+	expires time.Time
+}
+
+// NewLRUCache returns an LRUCache holding at most size documents.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{size: size, entries: map[string]*list.Element{}}
+}
+
+func (c *LRUCache) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *LRUCache) Get(key string) (*VAST, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !c.timeNow().Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.v, true
+}
+
+func (c *LRUCache) Set(key string, v *VAST, expires time.Time) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = &lruEntry{key: key, v: v, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, v: v, expires: expires})
+	for c.order.Len() > c.size {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of documents in the cache, expired ones included.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// cacheExpiry returns until when a response with header, received at now,
+// may be stored by a shared cache. Responses are only stored if they allow
+// it explicitly with a max-age, s-maxage or Expires.
+func cacheExpiry(header http.Header, now time.Time) (time.Time, bool) {
+	maxAge, sMaxAge := -1, -1
+	for _, h := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(h, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			value = strings.Trim(value, `"`)
+			switch strings.ToLower(name) {
+			case "no-store", "no-cache", "private":
+				return time.Time{}, false
+			case "max-age":
+				if n, err := strconv.Atoi(value); err == nil {
+					maxAge = n
+				}
+			case "s-maxage":
+				if n, err := strconv.Atoi(value); err == nil {
+					sMaxAge = n
+				}
+			}
+		}
+	}
+
+	var lifetime time.Duration
+	switch {
+	case sMaxAge >= 0:
+		lifetime = time.Duration(sMaxAge) * time.Second
+	case maxAge >= 0:
+		lifetime = time.Duration(maxAge) * time.Second
+	case header.Get("Expires") != "":
+		expires, err := http.ParseTime(header.Get("Expires"))
+		if err != nil {
+			return time.Time{}, false
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	default:
+		return time.Time{}, false
+	}
+	if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	if lifetime <= 0 {
+		return time.Time{}, false
+	}
+	return now.Add(lifetime), true
+}
+
+// cacheKey returns the Cache and flightGroup key of the fetch of url on
+// behalf of the viewer c.
+func cacheKey(url string, c ClientInfo) string {
+	if c == (ClientInfo{}) {
+		return url
+	}
+	// Header values and URLs cannot contain newlines.
+	return strings.Join([]string{url, c.UserAgent, c.IP, c.ForwardedFor, c.Referer, c.AcceptLanguage, c.RequestedWith}, "\n")
+}
+
+// flightGroup collapses concurrent fetches with the same key into one.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	hop  Hop
+}
+
+// do calls fetch unless a call for key is in flight, in which case it waits
+// for its result. The result is shared by all the callers. fetch runs in the
+// background: a caller whose ctx is done stops waiting for it and gets a Hop
+// with the error of ctx, the others still get its result.
+func (g *flightGroup) do(ctx context.Context, key string, fetch func() Hop) Hop {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		if g.calls == nil {
+			g.calls = map[string]*flightCall{}
+		}
+		c = &flightCall{done: make(chan struct{})}
+		g.calls[key] = c
+		go func() {
+			c.hop = fetch()
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.hop
+	case <-ctx.Done():
+		return Hop{Err: ctx.Err()}
+	}
+}
@@ -0,0 +1,205 @@
+package vast2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		header http.Header
+		ttl    time.Duration
+		ok     bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=60"}}, time.Minute, true},
+		{http.Header{"Cache-Control": {"public, max-age=60, s-maxage=30"}}, 30 * time.Second, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"20"}}, 40 * time.Second, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"60"}}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=0"}}, 0, false},
+		{http.Header{"Cache-Control": {"no-store, max-age=60"}}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=60", "no-cache"}}, 0, false},
+		{http.Header{"Cache-Control": {"private, max-age=60"}}, 0, false},
+		{http.Header{"Expires": {"Mon, 01 Jan 2024 12:05:00 GMT"}}, 5 * time.Minute, true},
+		{http.Header{"Expires": {"Mon, 01 Jan 2024 12:05:00 GMT"}, "Date": {"Mon, 01 Jan 2024 12:04:00 GMT"}}, time.Minute, true},
+		{http.Header{"Expires": {"0"}}, 0, false},
+		{http.Header{"Expires": {"Mon, 01 Jan 2024 12:05:00 GMT"}, "Cache-Control": {"max-age=10"}}, 10 * time.Second, true},
+	} {
+		expires, ok := cacheExpiry(tt.header, now)
+		assert.Equal(t, ok, tt.ok, tt.header)
+		if ok {
+			assert.Equal(t, expires.Sub(now), tt.ttl, tt.header)
+		}
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	now := time.Now()
+	c := NewLRUCache(2)
+	c.now = func() time.Time { return now }
+	a, b, d := New(), New(), New()
+
+	c.Set("a", a, now.Add(time.Minute))
+	c.Set("b", b, now.Add(time.Second))
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.True(t, v == a)
+
+	// b is the least recently used.
+	c.Set("d", d, now.Add(time.Minute))
+	assert.Equal(t, c.Len(), 2)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	c.Set("a", b, now.Add(time.Second))
+	v, ok = c.Get("a")
+	assert.True(t, ok)
+	assert.True(t, v == b)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, c.Len(), 1)
+
+	c = NewLRUCache(0)
+	c.Set("a", a, now.Add(time.Minute))
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestResolverCache(t *testing.T) {
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cached", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		serveVAST(t, New(testInLineAd()))(w, r)
+	})
+	mux.HandleFunc("/uncached", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		serveVAST(t, New(testInLineAd()))(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := &Resolver{Cache: NewLRUCache(10)}
+	hop := r.Fetch(context.Background(), server.URL+"/cached")
+	assert.Nil(t, hop.Err)
+	assert.False(t, hop.Cached)
+	hop.VAST.Ad[0].ID = "modified"
+
+	hop = r.Fetch(context.Background(), server.URL+"/cached")
+	assert.Nil(t, hop.Err)
+	assert.True(t, hop.Cached)
+	assert.Equal(t, hop.StatusCode, 200)
+	assert.Equal(t, hop.VAST, New(testInLineAd()))
+	hop.VAST.Ad[0].ID = "modified"
+	assert.Equal(t, int(requests.Load()), 1)
+
+	hop = r.Fetch(context.Background(), server.URL+"/cached")
+	assert.Equal(t, hop.VAST.Ad[0].ID, "inline")
+
+	r.Fetch(context.Background(), server.URL+"/uncached")
+	hop = r.Fetch(context.Background(), server.URL+"/uncached")
+	assert.False(t, hop.Cached)
+	assert.Equal(t, int(requests.Load()), 3)
+
+	hop = r.Fetch(context.Background(), server.URL+"/missing")
+	assert.Equal(t, hop.StatusCode, 404)
+	assert.NotNil(t, hop.Err)
+}
+
+func TestResolverCacheCollapsesFetches(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		serveVAST(t, New(testInLineAd()))(w, r)
+	}))
+	defer server.Close()
+
+	r := &Resolver{Cache: NewLRUCache(10)}
+	hops := make([]Hop, 10)
+	var wg sync.WaitGroup
+	for i := range hops {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hops[i] = r.Fetch(context.Background(), server.URL)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int(requests.Load()), 1)
+	for _, hop := range hops {
+		assert.Nil(t, hop.Err)
+		assert.Equal(t, hop.AdSystem(), "partner")
+	}
+	assert.True(t, hops[0].VAST != hops[1].VAST)
+}
+
+func TestResolverCacheCallerCancel(t *testing.T) {
+	var slowRequests atomic.Int32
+	slowStarted, releaseFast, releaseSlow := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		<-releaseFast
+		serveVAST(t, New(testInLineAd()))(w, r)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		if slowRequests.Add(1) == 1 {
+			close(slowStarted)
+		}
+		<-releaseSlow
+		serveVAST(t, New(testInLineAd()))(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := &Resolver{Cache: NewLRUCache(10)}
+	var first *TagResult
+	var results []TagResult
+	a := make(chan struct{})
+	go func() {
+		defer close(a)
+		first, results = r.FetchFirstValid(context.Background(), []string{server.URL + "/fast", server.URL + "/slow"})
+	}()
+	<-slowStarted
+	var hop Hop
+	b := make(chan struct{})
+	go func() {
+		defer close(b)
+		hop = r.Fetch(context.Background(), server.URL+"/slow")
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// A stops waiting for the slow tag once the fast one is valid, B goes
+	// on waiting for the shared request.
+	close(releaseFast)
+	<-a
+	assert.Equal(t, first.URL, server.URL+"/fast")
+	assert.ErrorIs(t, results[1].Err, context.Canceled)
+	close(releaseSlow)
+	<-b
+	assert.Nil(t, hop.Err)
+	assert.Equal(t, hop.AdSystem(), "partner")
+	assert.Equal(t, int(slowRequests.Load()), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r = &Resolver{Cache: NewLRUCache(10), FetchTimeout: time.Minute}
+	releaseSlow = make(chan struct{})
+	defer close(releaseSlow)
+	hop = r.Fetch(ctx, server.URL+"/slow")
+	assert.ErrorIs(t, hop.Err, context.DeadlineExceeded)
+}
@@ -0,0 +1,150 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+var (
+	// ErrAdChoice is returned when encoding an Ad with both InLine and
+	// Wrapper set.
+	ErrAdChoice = errors.New("vast2: Ad has both InLine and Wrapper")
+	// ErrCreativeChoice is returned when encoding a Creative with more than
+	// one of Linear, CompanionAds and NonLinearAds set.
+	ErrCreativeChoice = errors.New("vast2: Creative has more than one of Linear, CompanionAds and NonLinearAds")
+)
+
+// AdKind tells which of InLine and Wrapper is set on an Ad.
+type AdKind int
+
+const (
+	AdEmpty AdKind = iota
+	AdInLine
+	AdWrapper
+	// AdAmbiguous is the kind of Ads with both InLine and Wrapper set,
+	// which cannot be encoded.
+	AdAmbiguous
+)
+
+var adKindNames = [...]string{
+	AdEmpty:     "Empty",
+	AdInLine:    "InLine",
+	AdWrapper:   "Wrapper",
+	AdAmbiguous: "Ambiguous",
+}
+
+func (k AdKind) String() string {
+	if k < 0 || int(k) >= len(adKindNames) {
+		return "Unknown"
+	}
+	return adKindNames[k]
+}
+
+// CreativeKind tells which of Linear, CompanionAds and NonLinearAds is set
+// on a Creative.
+type CreativeKind int
+
+const (
+	CreativeEmpty CreativeKind = iota
+	CreativeLinear
+	CreativeCompanionAds
+	CreativeNonLinearAds
+	// CreativeAmbiguous is the kind of Creatives with more than one choice
+	// set, which cannot be encoded.
+	CreativeAmbiguous
+)
+
+var creativeKindNames = [...]string{
+	CreativeEmpty:        "Empty",
+	CreativeLinear:       "Linear",
+	CreativeCompanionAds: "CompanionAds",
+	CreativeNonLinearAds: "NonLinearAds",
+	CreativeAmbiguous:    "Ambiguous",
+}
+
+func (k CreativeKind) String() string {
+	if k < 0 || int(k) >= len(creativeKindNames) {
+		return "Unknown"
+	}
+	return creativeKindNames[k]
+}
+
+// NewInLineAd returns an Ad holding inLine.
+func NewInLineAd(id string, inLine *InLine) Ad {
+	return Ad{ID: id, InLine: inLine}
+}
+
+// NewWrapperAd returns an Ad holding wrapper.
+func NewWrapperAd(id string, wrapper *Wrapper) Ad {
+	return Ad{ID: id, Wrapper: wrapper}
+}
+
+// Kind returns which of InLine and Wrapper is set.
+func (a Ad) Kind() AdKind {
+	switch {
+	case a.InLine != nil && a.Wrapper != nil:
+		return AdAmbiguous
+	case a.InLine != nil:
+		return AdInLine
+	case a.Wrapper != nil:
+		return AdWrapper
+	}
+	return AdEmpty
+}
+
+// MarshalXML encodes a, failing with ErrAdChoice if its kind is
+// AdAmbiguous.
+func (a Ad) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if a.Kind() == AdAmbiguous {
+		return ErrAdChoice
+	}
+	type ad Ad
+	return e.EncodeElement(ad(a), start)
+}
+
+// NewLinearCreative returns a Creative holding linear.
+func NewLinearCreative(linear *Linear) Creative {
+	return Creative{Linear: linear}
+}
+
+// NewCompanionAdsCreative returns a Creative holding companionAds.
+func NewCompanionAdsCreative(companionAds *CompanionAds) Creative {
+	return Creative{CompanionAds: companionAds}
+}
+
+// NewNonLinearAdsCreative returns a Creative holding nonLinearAds.
+func NewNonLinearAdsCreative(nonLinearAds *NonLinearAds) Creative {
+	return Creative{NonLinearAds: nonLinearAds}
+}
+
+// Kind returns which of Linear, CompanionAds and NonLinearAds is set.
+func (c Creative) Kind() CreativeKind {
+	kind := CreativeEmpty
+	for _, k := range [...]struct {
+		set  bool
+		kind CreativeKind
+	}{
+		{c.Linear != nil, CreativeLinear},
+		{c.CompanionAds != nil, CreativeCompanionAds},
+		{c.NonLinearAds != nil, CreativeNonLinearAds},
+	} {
+		if !k.set {
+			continue
+		}
+		if kind != CreativeEmpty {
+			return CreativeAmbiguous
+		}
+		kind = k.kind
+	}
+	return kind
+}
+
+// MarshalXML encodes c, failing with ErrCreativeChoice if its kind is
+// CreativeAmbiguous.
+func (c Creative) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.Kind() == CreativeAmbiguous {
+		return ErrCreativeChoice
+	}
+	type creative Creative
+	return e.EncodeElement(creative(c), start)
+}
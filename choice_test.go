@@ -0,0 +1,70 @@
+package vast2
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdKind(t *testing.T) {
+	assert.Equal(t, Ad{}.Kind(), AdEmpty)
+	assert.Equal(t, NewInLineAd("1", &InLine{}).Kind(), AdInLine)
+	assert.Equal(t, NewWrapperAd("1", &Wrapper{}).Kind(), AdWrapper)
+	assert.Equal(t, Ad{InLine: &InLine{}, Wrapper: &Wrapper{}}.Kind(), AdAmbiguous)
+	assert.Equal(t, NewInLineAd("1", nil).Kind(), AdEmpty)
+	assert.Equal(t, AdWrapper.String(), "Wrapper")
+	assert.Equal(t, AdKind(-1).String(), "Unknown")
+}
+
+func TestCreativeKind(t *testing.T) {
+	assert.Equal(t, Creative{}.Kind(), CreativeEmpty)
+	assert.Equal(t, NewLinearCreative(&Linear{}).Kind(), CreativeLinear)
+	assert.Equal(t, NewCompanionAdsCreative(&CompanionAds{}).Kind(), CreativeCompanionAds)
+	assert.Equal(t, NewNonLinearAdsCreative(&NonLinearAds{}).Kind(), CreativeNonLinearAds)
+	assert.Equal(t, Creative{Linear: &Linear{}, NonLinearAds: &NonLinearAds{}}.Kind(), CreativeAmbiguous)
+	assert.Equal(t, Creative{CompanionAds: &CompanionAds{}, NonLinearAds: &NonLinearAds{}}.Kind(), CreativeAmbiguous)
+	assert.Equal(t, CreativeCompanionAds.String(), "CompanionAds")
+	assert.Equal(t, CreativeKind(10).String(), "Unknown")
+}
+
+func TestMarshalAmbiguousAd(t *testing.T) {
+	vast := &VAST{Version: "2.0", Ad: []Ad{{ID: "1", InLine: &InLine{}, Wrapper: &Wrapper{}}}}
+	_, err := xml.Marshal(vast)
+	assert.Equal(t, err, ErrAdChoice)
+
+	var buf bytes.Buffer
+	n, err := vast.WriteTo(&buf)
+	assert.Equal(t, err, ErrAdChoice)
+	assert.Equal(t, n, int64(0))
+	assert.Equal(t, buf.Len(), 0)
+
+	_, err = Format(vast, FormatOptions{})
+	assert.Equal(t, err, ErrAdChoice)
+	_, err = Format(vast, FormatOptions{Indent: "  "})
+	assert.Equal(t, err, ErrAdChoice)
+}
+
+func TestMarshalAmbiguousCreative(t *testing.T) {
+	inLine := &InLine{Creatives: Creatives{Creative: []Creative{
+		NewLinearCreative(&Linear{}),
+		{Linear: &Linear{}, CompanionAds: &CompanionAds{}},
+	}}}
+	vast := &VAST{Version: "2.0", Ad: []Ad{NewInLineAd("1", inLine)}}
+	_, err := xml.Marshal(vast)
+	assert.Equal(t, err, ErrCreativeChoice)
+
+	_, err = vast.WriteTo(&bytes.Buffer{})
+	assert.Equal(t, err, ErrCreativeChoice)
+	_, err = Format(vast, FormatOptions{CDATA: true})
+	assert.Equal(t, err, ErrCreativeChoice)
+}
+
+func TestDecodeAmbiguousAd(t *testing.T) {
+	data := `<VAST version="2.0"><Ad id="1"><InLine></InLine><Wrapper></Wrapper></Ad></VAST>`
+	var vast VAST
+	assert.Nil(t, xml.Unmarshal([]byte(data), &vast))
+	assert.Equal(t, vast.Ad[0].Kind(), AdAmbiguous)
+	assert.Equal(t, vast.Ad[0].ID, "1")
+}
@@ -0,0 +1,166 @@
+package vast2
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientInfo describes the viewer on whose behalf a server, such as an SSAI
+// stitcher, fetches tags and fires beacons. It is sent with the headers of
+// the IAB SSAI guidelines so that ad servers can target and count the
+// viewer rather than the server.
+type ClientInfo struct {
+	// UserAgent is sent as X-Device-User-Agent.
+	UserAgent string
+	// IP is sent as X-Device-IP.
+	IP string
+	// ForwardedFor is the chain of addresses sent as X-Forwarded-For, IP
+	// if empty.
+	ForwardedFor string
+	// Referer is sent as X-Device-Referer.
+	Referer string
+	// AcceptLanguage is sent as X-Device-Accept-Language.
+	AcceptLanguage string
+	// RequestedWith, the application identifier of apps, is sent as
+	// X-Device-Requested-With.
+	RequestedWith string
+}
+
+// ClientInfoFromRequest returns the ClientInfo of the viewer request r. The
+// IP is the remote address of r. The X-Forwarded-For header, which viewers
+// can forge, is only used if the remote address is one of trustedProxies:
+// the IP is then its last address not in trustedProxies, the chain of
+// addresses it traversed being kept in ForwardedFor.
+func ClientInfoFromRequest(r *http.Request, trustedProxies ...netip.Prefix) ClientInfo {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	info := ClientInfo{
+		UserAgent:      r.UserAgent(),
+		IP:             remote,
+		ForwardedFor:   remote,
+		Referer:        r.Referer(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		RequestedWith:  r.Header.Get("X-Requested-With"),
+	}
+	trusted := func(addr string) bool {
+		ip, err := netip.ParseAddr(addr)
+		if err != nil {
+			return false
+		}
+		for _, p := range trustedProxies {
+			if p.Contains(ip.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+	xff := strings.Join(r.Header.Values("X-Forwarded-For"), ", ")
+	if xff == "" || !trusted(remote) {
+		return info
+	}
+	addrs := strings.Split(xff, ",")
+	for i := len(addrs) - 1; i >= 0; i-- {
+		if addr := strings.TrimSpace(addrs[i]); addr != "" {
+			info.IP = addr
+			if !trusted(addr) {
+				break
+			}
+		}
+	}
+	info.ForwardedFor = xff + ", " + remote
+	return info
+}
+
+// SetHeaders sets the non-empty fields of c in h.
+func (c ClientInfo) SetHeaders(h http.Header) {
+	forwardedFor := c.ForwardedFor
+	if forwardedFor == "" {
+		forwardedFor = c.IP
+	}
+	for _, header := range [...]struct{ name, value string }{
+		{"X-Device-User-Agent", c.UserAgent},
+		{"X-Device-IP", c.IP},
+		{"X-Forwarded-For", forwardedFor},
+		{"X-Device-Referer", c.Referer},
+		{"X-Device-Accept-Language", c.AcceptLanguage},
+		{"X-Device-Requested-With", c.RequestedWith},
+	} {
+		if header.value != "" {
+			h.Set(header.name, header.value)
+		}
+	}
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo returns a copy of ctx carrying c. The requests made by a
+// Resolver or a Waterfall with the returned context send the headers of c.
+func WithClientInfo(ctx context.Context, c ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, c)
+}
+
+// ClientInfoFromContext returns the ClientInfo carried by ctx, if any.
+func ClientInfoFromContext(ctx context.Context) (ClientInfo, bool) {
+	c, ok := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return c, ok
+}
+
+// newRequest returns a GET request for url with the headers of the
+// ClientInfo of ctx, if any.
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := ClientInfoFromContext(ctx); ok {
+		c.SetHeaders(req.Header)
+	}
+	return req, nil
+}
+
+// Fire requests the non-empty urls concurrently, such as the Impression
+// and Tracking URLs of an ad played on behalf of the viewer of ctx, and
+// returns a Hop per URL, in order. The responses are discarded.
+func (r *Resolver) Fire(ctx context.Context, urls ...string) []Hop {
+	var hops []Hop
+	for _, url := range urls {
+		if url = strings.TrimSpace(url); url != "" {
+			hops = append(hops, Hop{URL: url})
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range hops {
+		wg.Add(1)
+		go func(hop *Hop) {
+			defer wg.Done()
+			start := time.Now()
+			defer func() { hop.Duration = time.Since(start) }()
+			req, err := newRequest(ctx, hop.URL)
+			if err != nil {
+				hop.Err = err
+				return
+			}
+			resp, err := r.client().Do(req)
+			if err != nil {
+				hop.Err = err
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			hop.StatusCode = resp.StatusCode
+			if resp.StatusCode >= http.StatusBadRequest {
+				hop.Err = &StatusError{URL: hop.URL, StatusCode: resp.StatusCode}
+			}
+		}(&hops[i])
+	}
+	wg.Wait()
+	return hops
+}
@@ -0,0 +1,231 @@
+package vast2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testClientInfo = ClientInfo{
+	UserAgent:      "Mozilla/5.0 (SmartTV)",
+	IP:             "203.0.113.7",
+	Referer:        "https://publisher.com/video",
+	AcceptLanguage: "de-DE",
+	RequestedWith:  "com.publisher.app",
+}
+
+// clientHeaders records the client headers of the requests by path.
+type clientHeaders struct {
+	mu      sync.Mutex
+	headers map[string]http.Header
+}
+
+func (c *clientHeaders) record(r *http.Request) {
+	h := http.Header{}
+	for _, name := range []string{"X-Device-User-Agent", "X-Device-IP", "X-Forwarded-For", "X-Device-Referer", "X-Device-Accept-Language", "X-Device-Requested-With"} {
+		if v := r.Header.Get(name); v != "" {
+			h.Set(name, v)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.headers == nil {
+		c.headers = map[string]http.Header{}
+	}
+	c.headers[r.URL.Path] = h
+}
+
+func (c *clientHeaders) get(path string) http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.headers[path]
+}
+
+var testClientHeaders = http.Header{
+	"X-Device-User-Agent":      {"Mozilla/5.0 (SmartTV)"},
+	"X-Device-Ip":              {"203.0.113.7"},
+	"X-Forwarded-For":          {"203.0.113.7"},
+	"X-Device-Referer":         {"https://publisher.com/video"},
+	"X-Device-Accept-Language": {"de-DE"},
+	"X-Device-Requested-With":  {"com.publisher.app"},
+}
+
+func TestClientInfoResolveAd(t *testing.T) {
+	headers := &clientHeaders{}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers.record(r)
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	mux.HandleFunc("/w2", serveVAST(t, New(testWrapperAd("w2", server.URL+"/inline"))))
+	mux.HandleFunc("/inline", serveVAST(t, New(testInLineAd())))
+
+	ctx := WithClientInfo(context.Background(), testClientInfo)
+	ad := testWrapperAd("w1", server.URL+"/w2")
+	_, hops, err := (&Resolver{}).ResolveAd(ctx, &ad)
+	assert.Nil(t, err)
+	assert.Len(t, hops, 2)
+	assert.Equal(t, headers.get("/w2"), testClientHeaders)
+	assert.Equal(t, headers.get("/inline"), testClientHeaders)
+
+	_, _, err = (&Resolver{}).ResolveAd(context.Background(), &ad)
+	assert.Nil(t, err)
+	assert.Equal(t, headers.get("/inline"), http.Header{})
+}
+
+func TestResolverFire(t *testing.T) {
+	headers := &clientHeaders{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers.record(r)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := WithClientInfo(context.Background(), testClientInfo)
+	hops := (&Resolver{}).Fire(ctx, server.URL+"/impression", "", " "+server.URL+"/start ", server.URL+"/missing")
+	assert.Len(t, hops, 3)
+	assert.Equal(t, hops[0].URL, server.URL+"/impression")
+	assert.Equal(t, hops[0].StatusCode, http.StatusNoContent)
+	assert.Nil(t, hops[0].Err)
+	assert.Equal(t, hops[1].URL, server.URL+"/start")
+	assert.Equal(t, hops[2].Err, &StatusError{URL: server.URL + "/missing", StatusCode: 404})
+	for _, path := range []string{"/impression", "/start", "/missing"} {
+		assert.Equal(t, headers.get(path), testClientHeaders, path)
+	}
+}
+
+func TestClientInfoWaterfall(t *testing.T) {
+	headers := &clientHeaders{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers.record(r)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := WithClientInfo(context.Background(), testClientInfo)
+	ad := testWaterfallAd("webm", server.URL+"/error/"+ErrorCodeMacro, "video/webm")
+	w := &Waterfall{MIMETypes: []string{"video/mp4"}}
+	_, _, err := w.Select(ctx, New(ad))
+	assert.Equal(t, err, ErrNoPlayableAd)
+	w.Wait()
+	assert.Equal(t, headers.get("/error/403"), testClientHeaders)
+}
+
+func TestClientInfoFromRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/vast", nil)
+	r.RemoteAddr = "198.51.100.1:1234"
+	r.Header.Set("User-Agent", "Roku/DVP-9.10")
+	r.Header.Set("Referer", "https://publisher.com")
+	r.Header.Set("Accept-Language", "en-US")
+	r.Header.Set("X-Requested-With", "com.roku.app")
+	assert.Equal(t, ClientInfoFromRequest(r), ClientInfo{
+		UserAgent:      "Roku/DVP-9.10",
+		IP:             "198.51.100.1",
+		ForwardedFor:   "198.51.100.1",
+		Referer:        "https://publisher.com",
+		AcceptLanguage: "en-US",
+		RequestedWith:  "com.roku.app",
+	})
+
+	r.Header.Add("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	r.Header.Add("X-Forwarded-For", "10.0.0.2")
+	info := ClientInfoFromRequest(r)
+	assert.Equal(t, info.IP, "198.51.100.1")
+	assert.Equal(t, info.ForwardedFor, "198.51.100.1")
+
+	// Only trusted proxies are believed.
+	info = ClientInfoFromRequest(r, netip.MustParsePrefix("10.0.0.0/8"))
+	assert.Equal(t, info.IP, "198.51.100.1")
+	assert.Equal(t, info.ForwardedFor, "198.51.100.1")
+
+	proxies := []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24"), netip.MustParsePrefix("10.0.0.0/8")}
+	info = ClientInfoFromRequest(r, proxies...)
+	assert.Equal(t, info.IP, "203.0.113.7")
+	assert.Equal(t, info.ForwardedFor, "203.0.113.7, 10.0.0.1, 10.0.0.2, 198.51.100.1")
+
+	h := http.Header{}
+	info.SetHeaders(h)
+	assert.Equal(t, h.Get("X-Forwarded-For"), "203.0.113.7, 10.0.0.1, 10.0.0.2, 198.51.100.1")
+	assert.Equal(t, h.Get("X-Device-IP"), "203.0.113.7")
+
+	// The addresses prepended by the viewer are ignored.
+	r.Header.Set("X-Forwarded-For", "192.0.2.1, 203.0.113.7")
+	info = ClientInfoFromRequest(r, proxies...)
+	assert.Equal(t, info.IP, "203.0.113.7")
+	assert.Equal(t, info.ForwardedFor, "192.0.2.1, 203.0.113.7, 198.51.100.1")
+
+	_, ok := ClientInfoFromContext(context.Background())
+	assert.False(t, ok)
+	info, ok = ClientInfoFromContext(WithClientInfo(context.Background(), testClientInfo))
+	assert.True(t, ok)
+	assert.Equal(t, info, testClientInfo)
+}
+
+func TestClientInfoCollapsedFetches(t *testing.T) {
+	var mu sync.Mutex
+	ips := map[string]int{}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ips[r.Header.Get("X-Device-IP")]++
+		mu.Unlock()
+		<-release
+		serveVAST(t, New(testInLineAd()))(w, r)
+	}))
+	defer server.Close()
+
+	r := &Resolver{Cache: NewLRUCache(10)}
+	viewers := []string{"203.0.113.7", "203.0.113.8", "203.0.113.7"}
+	var wg sync.WaitGroup
+	for _, ip := range viewers {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			hop := r.Fetch(WithClientInfo(context.Background(), ClientInfo{IP: ip}), server.URL)
+			assert.Nil(t, hop.Err)
+		}(ip)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, ips, map[string]int{"203.0.113.7": 1, "203.0.113.8": 1})
+}
+
+func TestClientInfoCache(t *testing.T) {
+	var mu sync.Mutex
+	ips := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.Header.Get("X-Device-IP")
+		mu.Lock()
+		ips[ip]++
+		mu.Unlock()
+		ad := testInLineAd()
+		ad.ID = ip
+		w.Header().Set("Cache-Control", "max-age=60")
+		serveVAST(t, New(ad))(w, r)
+	}))
+	defer server.Close()
+
+	r := &Resolver{Cache: NewLRUCache(10)}
+	for _, ip := range []string{"203.0.113.7", "203.0.113.8", "203.0.113.7", "203.0.113.8"} {
+		hop := r.Fetch(WithClientInfo(context.Background(), ClientInfo{IP: ip}), server.URL)
+		assert.Nil(t, hop.Err)
+		assert.Equal(t, hop.URL, server.URL)
+		assert.Equal(t, hop.VAST.Ad[0].ID, ip)
+	}
+	hop := r.Fetch(context.Background(), server.URL)
+	assert.False(t, hop.Cached)
+	assert.Equal(t, hop.VAST.Ad[0].ID, "")
+	assert.Equal(t, ips, map[string]int{"203.0.113.7": 1, "203.0.113.8": 1, "": 1})
+}
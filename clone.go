@@ -0,0 +1,166 @@
+package vast2
+
+// copySlice returns a copy of s, keeping nil slices nil.
+func copySlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	c := make([]T, len(s))
+	copy(c, s)
+	return c
+}
+
+// cloneSlice returns a slice holding clone(e) for every element e of s.
+func cloneSlice[T any](s []T, clone func(T) T) []T {
+	if s == nil {
+		return nil
+	}
+	c := make([]T, len(s))
+	for i, e := range s {
+		c[i] = clone(e)
+	}
+	return c
+}
+
+// Clone returns a deep copy of v sharing no memory with it.
+func (v *VAST) Clone() *VAST {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	c.Ad = cloneSlice(v.Ad, Ad.Clone)
+	return &c
+}
+
+func (a Ad) Clone() Ad {
+	a.InLine = a.InLine.Clone()
+	a.Wrapper = a.Wrapper.Clone()
+	return a
+}
+
+func (i *InLine) Clone() *InLine {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	c.Error = copySlice(i.Error)
+	c.Impression = copySlice(i.Impression)
+	c.Creatives = i.Creatives.Clone()
+	c.Extensions = i.Extensions.Clone()
+	return &c
+}
+
+func (w *Wrapper) Clone() *Wrapper {
+	if w == nil {
+		return nil
+	}
+	c := *w
+	c.Error = copySlice(w.Error)
+	c.Impression = copySlice(w.Impression)
+	c.Creatives = w.Creatives.Clone()
+	c.Extensions = w.Extensions.Clone()
+	return &c
+}
+
+func (c Creatives) Clone() Creatives {
+	c.Creative = cloneSlice(c.Creative, Creative.Clone)
+	return c
+}
+
+func (c Creative) Clone() Creative {
+	c.Linear = c.Linear.Clone()
+	c.CompanionAds = c.CompanionAds.Clone()
+	c.NonLinearAds = c.NonLinearAds.Clone()
+	return c
+}
+
+func (l *Linear) Clone() *Linear {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	c.TrackingEvents = l.TrackingEvents.Clone()
+	c.VideoClicks = l.VideoClicks.Clone()
+	c.MediaFiles.MediaFile = copySlice(l.MediaFiles.MediaFile)
+	c.CreativeExtensions = l.CreativeExtensions.Clone()
+	return &c
+}
+
+func (t *TrackingEvents) Clone() *TrackingEvents {
+	if t == nil {
+		return nil
+	}
+	return &TrackingEvents{Tracking: copySlice(t.Tracking)}
+}
+
+func (v *VideoClicks) Clone() *VideoClicks {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	c.ClickTracking = copySlice(v.ClickTracking)
+	c.CustomClick = copySlice(v.CustomClick)
+	return &c
+}
+
+func (c *CompanionAds) Clone() *CompanionAds {
+	if c == nil {
+		return nil
+	}
+	return &CompanionAds{Companion: cloneSlice(c.Companion, Companion.Clone)}
+}
+
+func (c Companion) Clone() Companion {
+	c.StaticResource = c.StaticResource.Clone()
+	c.TrackingEvents = c.TrackingEvents.Clone()
+	c.CreativeExtensions = c.CreativeExtensions.Clone()
+	return c
+}
+
+func (s *StaticResource) Clone() *StaticResource {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func (n *NonLinearAds) Clone() *NonLinearAds {
+	if n == nil {
+		return nil
+	}
+	return &NonLinearAds{
+		NonLinear:      cloneSlice(n.NonLinear, NonLinear.Clone),
+		TrackingEvents: n.TrackingEvents.Clone(),
+	}
+}
+
+func (n NonLinear) Clone() NonLinear {
+	n.StaticResource = n.StaticResource.Clone()
+	n.CreativeExtensions = n.CreativeExtensions.Clone()
+	return n
+}
+
+func (c *CreativeExtensions) Clone() *CreativeExtensions {
+	if c == nil {
+		return nil
+	}
+	return &CreativeExtensions{CreativeExtension: cloneSlice(c.CreativeExtension, CreativeExtension.Clone)}
+}
+
+func (e CreativeExtension) Clone() CreativeExtension {
+	e.Data = copySlice(e.Data)
+	return e
+}
+
+func (e *Extensions) Clone() *Extensions {
+	if e == nil {
+		return nil
+	}
+	return &Extensions{Extension: cloneSlice(e.Extension, Extension.Clone)}
+}
+
+func (e Extension) Clone() Extension {
+	e.Data = copySlice(e.Data)
+	return e
+}
@@ -0,0 +1,128 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCloneVAST() *VAST {
+	return &VAST{
+		Version: "2.0",
+		Ad: []Ad{
+			{
+				ID: "1",
+				InLine: &InLine{
+					AdTitle:    "Title",
+					Error:      []string{"http://err.com"},
+					AdSystem:   AdSystem{Version: "1.0", Data: "pubnative"},
+					Impression: []Impression{{ID: "i", Data: "http://imp.com"}},
+					Creatives: Creatives{Creative: []Creative{
+						{
+							ID: "c1",
+							Linear: &Linear{
+								Duration:       "00:00:30",
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "start", Data: "http://start.com"}}},
+								VideoClicks: &VideoClicks{
+									ClickThrough:  "http://click.com",
+									ClickTracking: []ClickTracking{{ID: "ct", Data: "http://ct.com"}},
+									CustomClick:   []CustomClick{{ID: "cc", Data: "http://custom.com"}},
+								},
+								MediaFiles: MediaFiles{MediaFile: []MediaFile{{Type: "video/mp4", Width: 640, Height: 360, Data: "http://media.com/a.mp4"}}},
+								CreativeExtensions: &CreativeExtensions{CreativeExtension: []CreativeExtension{
+									{Type: "skip", Data: []byte("<Offset>5</Offset>")},
+								}},
+							},
+						},
+						{
+							ID: "c2",
+							CompanionAds: &CompanionAds{Companion: []Companion{{
+								Width:          300,
+								Height:         250,
+								StaticResource: &StaticResource{CreativeType: "image/png", Data: "http://static.com/a.png"},
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "creativeView", Data: "http://cv.com"}}},
+							}}},
+						},
+						{
+							ID: "c3",
+							NonLinearAds: &NonLinearAds{
+								NonLinear: []NonLinear{{
+									Width:                300,
+									Height:               50,
+									MinSuggestedDuration: "00:00:10",
+									StaticResource:       &StaticResource{Data: "http://nl.com/a.png"},
+								}},
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "expand", Data: "http://expand.com"}}},
+							},
+						},
+					}},
+					Extensions: &Extensions{Extension: []Extension{{Type: "pricing", Data: []byte("<Price>2.5</Price>")}}},
+				},
+			},
+			{
+				ID: "2",
+				Wrapper: &Wrapper{
+					VASTAdTagURI: "http://tag.com",
+					Impression:   []Impression{{Data: "http://wrapper-imp.com"}},
+					Extensions:   &Extensions{Extension: []Extension{{Type: "waterfall", Data: []byte("<Index>1</Index>")}}},
+				},
+			},
+		},
+	}
+}
+
+func TestCloneNil(t *testing.T) {
+	var vast *VAST
+	assert.Nil(t, vast.Clone())
+}
+
+func TestCloneEqual(t *testing.T) {
+	vast := testCloneVAST()
+	assert.Equal(t, vast.Clone(), vast)
+	assert.Equal(t, (&VAST{}).Clone(), &VAST{})
+}
+
+func TestCloneIndependent(t *testing.T) {
+	vast := testCloneVAST()
+	clone := vast.Clone()
+
+	RewriteURLs(clone, func(p Path, kind URLKind, url string) string {
+		return "http://proxy.com/?u=" + url
+	})
+	inLine := clone.Ad[0].InLine
+	inLine.AdTitle = "Changed"
+	inLine.Creatives.Creative[0].Linear.VideoClicks.ClickTracking[0].Data = "http://changed.com"
+	inLine.Creatives.Creative[0].Linear.MediaFiles.MediaFile[0].Width = 1
+	inLine.Creatives.Creative[1].CompanionAds.Companion[0].StaticResource.CreativeType = "image/gif"
+	inLine.Error[0] = "http://changed.com"
+	inLine.Creatives.Creative[0].Linear.VideoClicks.CustomClick[0].ID = "changed"
+	inLine.Creatives.Creative[0].Linear.CreativeExtensions.CreativeExtension[0].Data[1] = 'X'
+	inLine.Extensions.Extension[0].Data[1] = 'X'
+	clone.Ad[1].Wrapper.Extensions.Extension[0].Data = nil
+
+	assert.Equal(t, vast, testCloneVAST())
+}
+
+func BenchmarkClone(b *testing.B) {
+	vast := testCloneVAST()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vast.Clone()
+	}
+}
+
+func BenchmarkCloneXML(b *testing.B) {
+	vast := testCloneVAST()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, err := xml.Marshal(vast)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var clone VAST
+		if err := xml.Unmarshal(data, &clone); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Command vastfmt formats VAST 2.0 documents.
+//
+// Usage:
+//
+//	vastfmt [-m] [-indent str] [-cdata] [-l] [-w] [file...]
+//
+// Documents are decoded, canonicalised and re-encoded in schema order. Without
+// file arguments the document is read from stdin and written to stdout.
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("vastfmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	minify := flags.Bool("m", false, "minify instead of pretty-printing")
+	indent := flags.String("indent", "  ", "indentation used to pretty-print")
+	cdata := flags.Bool("cdata", false, "wrap URLs in CDATA sections")
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write the result to the files instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := vast2.FormatOptions{Indent: *indent, CDATA: *cdata}
+	if *minify {
+		opts.Indent = ""
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "vastfmt: cannot use -w with stdin")
+			return 2
+		}
+		data, err := io.ReadAll(stdin)
+		if err == nil {
+			data, err = format(data, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "vastfmt: <stdin>: %v\n", err)
+			return 2
+		}
+		stdout.Write(data)
+		return 0
+	}
+
+	code := 0
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "vastfmt: %v\n", err)
+			code = 2
+			continue
+		}
+		data, err := format(src, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "vastfmt: %s: %v\n", name, err)
+			code = 2
+			continue
+		}
+		changed := !bytes.Equal(src, data)
+		if *list && changed {
+			fmt.Fprintln(stdout, name)
+		}
+		if *write {
+			if changed {
+				if err := os.WriteFile(name, data, 0o644); err != nil {
+					fmt.Fprintf(stderr, "vastfmt: %v\n", err)
+					code = 2
+				}
+			}
+			continue
+		}
+		if !*list {
+			stdout.Write(data)
+		}
+	}
+	return code
+}
+
+// format decodes, canonicalises and re-encodes a document.
+func format(src []byte, opts vast2.FormatOptions) ([]byte, error) {
+	var v vast2.VAST
+	if err := xml.Unmarshal(src, &v); err != nil {
+		return nil, err
+	}
+	vast2.Canonicalize(&v)
+	data, err := vast2.Format(&v, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
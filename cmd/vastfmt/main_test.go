@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const src = `<VAST version="2.0"><Ad id="1"><Wrapper>
+<VASTAdTagURI>
+  http://tag.com/vast.xml
+</VASTAdTagURI>
+<AdSystem>partner</AdSystem><Impression>http://imp.com</Impression>
+</Wrapper></Ad></VAST>`
+
+const pretty = `<?xml version="1.0" encoding="UTF-8"?>
+<VAST version="2.0">
+  <Ad id="1">
+    <Wrapper>
+      <VASTAdTagURI>http://tag.com/vast.xml</VASTAdTagURI>
+      <AdSystem>partner</AdSystem>
+      <Impression>http://imp.com</Impression>
+      <Creatives></Creatives>
+    </Wrapper>
+  </Ad>
+</VAST>
+`
+
+func TestRunStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, run(nil, strings.NewReader(src), &stdout, &stderr), 0)
+	assert.Equal(t, stdout.String(), pretty)
+}
+
+func TestRunMinifyCDATA(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, run([]string{"-m", "-cdata"}, strings.NewReader(src), &stdout, &stderr), 0)
+
+	res := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<VAST version="2.0"><Ad id="1"><Wrapper>` +
+		`<VASTAdTagURI><![CDATA[http://tag.com/vast.xml]]></VASTAdTagURI>` +
+		`<AdSystem>partner</AdSystem>` +
+		`<Impression><![CDATA[http://imp.com]]></Impression>` +
+		`<Creatives></Creatives>` +
+		`</Wrapper></Ad></VAST>` + "\n"
+	assert.Equal(t, stdout.String(), res)
+}
+
+func TestRunListWrite(t *testing.T) {
+	dir := t.TempDir()
+	ugly := filepath.Join(dir, "ugly.xml")
+	formatted := filepath.Join(dir, "formatted.xml")
+	assert.Nil(t, os.WriteFile(ugly, []byte(src), 0o644))
+	assert.Nil(t, os.WriteFile(formatted, []byte(pretty), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, run([]string{"-l", "-w", ugly, formatted}, nil, &stdout, &stderr), 0)
+	assert.Equal(t, stdout.String(), ugly+"\n")
+
+	data, err := os.ReadFile(ugly)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), pretty)
+}
+
+func TestRunInvalid(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, run(nil, strings.NewReader("<VAST>"), &stdout, &stderr), 2)
+	assert.Equal(t, stdout.String(), "")
+	assert.Contains(t, stderr.String(), "vastfmt: <stdin>: ")
+
+	assert.Equal(t, run([]string{"-w"}, strings.NewReader(src), &stdout, &stderr), 2)
+}
@@ -0,0 +1,201 @@
+// Command vastlint decodes VAST 2.0 documents and checks them against the
+// specification.
+//
+// Usage:
+//
+//	vastlint [-json] [-strict] [file|url|-]...
+//
+// Documents are read from the given files, http(s) tag URLs or, without
+// arguments, from stdin. The exit code is 0 when no errors were found, 1 when
+// a document has errors (or warnings with -strict) and 2 when a document
+// could not be read.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+type problem struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("vastlint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonOutput := flags.Bool("json", false, "print problems as JSON")
+	strict := flags.Bool("strict", false, "fail on warnings")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	names := flags.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	code := 0
+	problems := []problem{}
+	for _, name := range names {
+		data, err := read(name, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "vastlint: %v\n", err)
+			code = 2
+			continue
+		}
+		for _, p := range lint(name, data) {
+			if p.Severity == vast2.SeverityError.String() || *strict {
+				code = max(code, 1)
+			}
+			problems = append(problems, p)
+		}
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(problems)
+		return code
+	}
+	for _, p := range problems {
+		loc := p.File
+		if p.Line > 0 {
+			loc += ":" + strconv.Itoa(p.Line)
+		}
+		if p.Path != "" {
+			fmt.Fprintf(stdout, "%s: %s: %s: %s\n", loc, p.Severity, p.Path, p.Message)
+		} else {
+			fmt.Fprintf(stdout, "%s: %s: %s\n", loc, p.Severity, p.Message)
+		}
+	}
+	return code
+}
+
+func read(name string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case name == "-":
+		return io.ReadAll(stdin)
+	case strings.HasPrefix(name, "http://"), strings.HasPrefix(name, "https://"):
+		client := http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: unexpected status %s", name, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}
+	return os.ReadFile(name)
+}
+
+// lint decodes and validates a document and returns the problems found.
+func lint(name string, data []byte) []problem {
+	if name == "-" {
+		name = "<stdin>"
+	}
+
+	var v vast2.VAST
+	if err := xml.Unmarshal(data, &v); err != nil {
+		p := problem{File: name, Severity: vast2.SeverityError.String(), Message: err.Error()}
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			p.Line = syntaxErr.Line
+			p.Message = syntaxErr.Msg
+		}
+		return []problem{p}
+	}
+
+	lines := elementLines(data)
+	var problems []problem
+	for _, err := range vast2.Validate(&v) {
+		path := err.Path.String()
+		problems = append(problems, problem{
+			File:     name,
+			Line:     lines[path],
+			Severity: err.Severity.String(),
+			Path:     path,
+			Message:  err.Message,
+		})
+	}
+	return problems
+}
+
+// repeated are the elements which get an index in paths.
+var repeated = map[string]bool{
+	"Ad":            true,
+	"Error":         true,
+	"Impression":    true,
+	"Creative":      true,
+	"Tracking":      true,
+	"ClickTracking": true,
+	"CustomClick":   true,
+	"MediaFile":     true,
+	"Companion":     true,
+	"NonLinear":     true,
+	"Extension":     true,
+}
+
+// elementLines maps the paths of the elements in data, as reported by
+// vast2.Walk, to the lines they start at. The document element maps to "".
+func elementLines(data []byte) map[string]int {
+	lines := map[string]int{}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	type frame struct {
+		path   string
+		counts map[string]int
+	}
+	var stack []frame
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return lines
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			line, _ := dec.InputPos()
+			if len(stack) == 0 {
+				lines[""] = line
+				stack = append(stack, frame{counts: map[string]int{}})
+				continue
+			}
+			parent := &stack[len(stack)-1]
+			name := t.Name.Local
+			if repeated[name] {
+				name += "[" + strconv.Itoa(parent.counts[name]) + "]"
+				parent.counts[t.Name.Local]++
+			}
+			path := name
+			if parent.path != "" {
+				path = parent.path + "/" + name
+			}
+			if _, ok := lines[path]; !ok {
+				lines[path] = line
+			}
+			stack = append(stack, frame{path: path, counts: map[string]int{}})
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validVAST = `<VAST version="2.0">
+  <Ad id="1">
+    <InLine>
+      <AdSystem>pubnative</AdSystem>
+      <AdTitle>Title</AdTitle>
+      <Impression>http://imp.com</Impression>
+      <Creatives>
+        <Creative>
+          <Linear>
+            <Duration>00:00:30</Duration>
+            <MediaFiles>
+              <MediaFile delivery="progressive" type="video/mp4" width="640" height="360">http://media.com/a.mp4</MediaFile>
+            </MediaFiles>
+          </Linear>
+        </Creative>
+      </Creatives>
+    </InLine>
+  </Ad>
+</VAST>`
+
+const invalidVAST = `<VAST version="2.0">
+  <Ad id="1">
+    <InLine>
+      <AdSystem>pubnative</AdSystem>
+      <AdTitle>Title</AdTitle>
+      <Impression>http://imp.com</Impression>
+      <Creatives>
+        <Creative>
+          <Linear>
+            <Duration>30</Duration>
+            <TrackingEvents>
+              <Tracking event="begin">http://start.com</Tracking>
+            </TrackingEvents>
+            <MediaFiles>
+              <MediaFile delivery="progressive" type="video/mp4" width="640" height="360">http://media.com/a.mp4</MediaFile>
+            </MediaFiles>
+          </Linear>
+        </Creative>
+      </Creatives>
+    </InLine>
+  </Ad>
+</VAST>`
+
+func TestRunValid(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run(nil, strings.NewReader(validVAST), &stdout, &stderr)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, stdout.String(), "")
+	assert.Equal(t, stderr.String(), "")
+}
+
+func TestRunInvalid(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-"}, strings.NewReader(invalidVAST), &stdout, &stderr)
+	assert.Equal(t, code, 1)
+
+	res := `<stdin>:9: error: Ad[0]/InLine/Creatives/Creative[0]/Linear: invalid Duration "30"` + "\n" +
+		`<stdin>:12: warning: Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]: unknown event "begin"` + "\n"
+	assert.Equal(t, stdout.String(), res)
+}
+
+func TestRunWarningsStrict(t *testing.T) {
+	data := strings.Replace(validVAST, "<Impression>http://imp.com</Impression>", "<Impression>imp</Impression>", 1)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, run(nil, strings.NewReader(data), &stdout, &stderr), 0)
+	assert.Equal(t, stdout.String(), `<stdin>:6: warning: Ad[0]/InLine/Impression[0]: Impression is not an absolute http(s) URL: "imp"`+"\n")
+
+	stdout.Reset()
+	assert.Equal(t, run([]string{"-strict"}, strings.NewReader(data), &stdout, &stderr), 1)
+}
+
+func TestRunSyntaxError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run(nil, strings.NewReader("<VAST version=\"2.0\">\n<Ad>\n</VAST>"), &stdout, &stderr)
+	assert.Equal(t, code, 1)
+	assert.Equal(t, stdout.String(), "<stdin>:3: error: element <Ad> closed by </VAST>\n")
+}
+
+func TestRunJSON(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "ad.xml")
+	assert.Nil(t, os.WriteFile(name, []byte(invalidVAST), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-json", name}, nil, &stdout, &stderr)
+	assert.Equal(t, code, 1)
+
+	var problems []problem
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &problems))
+	assert.Equal(t, len(problems), 2)
+	assert.Equal(t, problems[0].File, name)
+	assert.Equal(t, problems[0].Severity, "error")
+	assert.Equal(t, problems[0].Path, "Ad[0]/InLine/Creatives/Creative[0]/Linear")
+	assert.Equal(t, problems[1].Severity, "warning")
+}
+
+func TestRunMissingFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{filepath.Join(t.TempDir(), "missing.xml")}, nil, &stdout, &stderr)
+	assert.Equal(t, code, 2)
+	assert.Contains(t, stderr.String(), "vastlint: ")
+}
+
+func TestElementLines(t *testing.T) {
+	lines := elementLines([]byte(invalidVAST))
+	assert.Equal(t, lines[""], 1)
+	assert.Equal(t, lines["Ad[0]"], 2)
+	assert.Equal(t, lines["Ad[0]/InLine/Impression[0]"], 6)
+	assert.Equal(t, lines["Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]"], 15)
+}
@@ -0,0 +1,122 @@
+// Command vastunwrap follows the Wrapper chains of a VAST 2.0 tag.
+//
+// Usage:
+//
+//	vastunwrap [-timeout d] [-max-depth n] [-record dir | -replay dir] tag-url|file
+//
+// Every hop is printed to stderr with its status, timing and AdSystem, the
+// resolved document with the wrapper tracking merged into the InLine Ads is
+// written to stdout. With -record the responses are saved to dir, with
+// -replay they are read back from dir without any network access.
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("vastunwrap", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout for the whole resolution")
+	maxDepth := flags.Int("max-depth", vast2.DefaultMaxWrapperDepth, "maximum number of wrappers to follow")
+	record := flags.String("record", "", "save the responses to `dir`")
+	replay := flags.String("replay", "", "read the responses from `dir` instead of the network")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || (*record != "" && *replay != "") {
+		flags.Usage()
+		return 2
+	}
+
+	client := &http.Client{}
+	switch {
+	case *replay != "":
+		client.Transport = replayTransport{dir: *replay}
+	case *record != "":
+		if err := os.MkdirAll(*record, 0o755); err != nil {
+			fmt.Fprintf(stderr, "vastunwrap: %v\n", err)
+			return 2
+		}
+		client.Transport = recordTransport{dir: *record, next: http.DefaultTransport}
+	}
+	r := &vast2.Resolver{Client: client, MaxDepth: *maxDepth}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	src := flags.Arg(0)
+	var v *vast2.VAST
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		hop := r.Fetch(ctx, src)
+		printHop(stderr, 0, hop)
+		if hop.Err != nil {
+			fmt.Fprintf(stderr, "vastunwrap: %v\n", hop.Err)
+			return 1
+		}
+		v = hop.VAST
+	} else {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			fmt.Fprintf(stderr, "vastunwrap: %v\n", err)
+			return 2
+		}
+		v = &vast2.VAST{}
+		if err := xml.Unmarshal(data, v); err != nil {
+			fmt.Fprintf(stderr, "vastunwrap: %s: %v\n", src, err)
+			return 1
+		}
+	}
+
+	code := 0
+	resolved := &vast2.VAST{Version: v.Version}
+	for i := range v.Ad {
+		fmt.Fprintf(stderr, "Ad[%d] id=%q\n", i, v.Ad[i].ID)
+		ad, hops, err := r.ResolveAd(ctx, &v.Ad[i])
+		for j, hop := range hops {
+			printHop(stderr, j+1, hop)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "vastunwrap: Ad[%d]: %v\n", i, err)
+			code = 1
+			continue
+		}
+		resolved.Ad = append(resolved.Ad, *ad)
+	}
+
+	data, err := vast2.Format(resolved, vast2.FormatOptions{Indent: "  "})
+	if err != nil {
+		fmt.Fprintf(stderr, "vastunwrap: %v\n", err)
+		return 1
+	}
+	io.WriteString(stdout, xml.Header)
+	stdout.Write(data)
+	io.WriteString(stdout, "\n")
+	return code
+}
+
+func printHop(w io.Writer, i int, hop vast2.Hop) {
+	status := "---"
+	if hop.StatusCode != 0 {
+		status = fmt.Sprint(hop.StatusCode)
+	}
+	adSystem := hop.AdSystem()
+	if adSystem == "" {
+		adSystem = "-"
+	}
+	fmt.Fprintf(w, "  #%d %s %6dms %s %s\n", i, status, hop.Duration.Milliseconds(), adSystem, hop.URL)
+}
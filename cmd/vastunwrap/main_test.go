@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/tag", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<VAST version="2.0"><Ad id="1"><Wrapper>` +
+			`<VASTAdTagURI>` + server.URL + `/inline</VASTAdTagURI>` +
+			`<AdSystem>wrapper</AdSystem>` +
+			`<Impression>http://imp.com/wrapper</Impression>` +
+			`</Wrapper></Ad></VAST>`))
+	})
+	mux.HandleFunc("/inline", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<VAST version="2.0"><Ad id="2"><InLine>` +
+			`<AdTitle>Title</AdTitle>` +
+			`<AdSystem>partner</AdSystem>` +
+			`<Impression>http://imp.com/inline</Impression>` +
+			`<Creatives></Creatives>` +
+			`</InLine></Ad></VAST>`))
+	})
+	return server
+}
+
+const resolved = `<?xml version="1.0" encoding="UTF-8"?>
+<VAST version="2.0">
+  <Ad id="1">
+    <InLine>
+      <AdTitle>Title</AdTitle>
+      <AdSystem>partner</AdSystem>
+      <Impression>http://imp.com/inline</Impression>
+      <Impression>http://imp.com/wrapper</Impression>
+      <Creatives></Creatives>
+    </InLine>
+  </Ad>
+</VAST>
+`
+
+var timing = regexp.MustCompile(` +\d+ms `)
+
+func TestRunRecordReplay(t *testing.T) {
+	server := testServer()
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-record", dir, server.URL + "/tag"}, &stdout, &stderr)
+	server.Close()
+	assert.Equal(t, code, 0)
+	assert.Equal(t, stdout.String(), resolved)
+
+	chain := "  #0 200 0ms wrapper " + server.URL + "/tag\n" +
+		"Ad[0] id=\"1\"\n" +
+		"  #1 200 0ms partner " + server.URL + "/inline\n"
+	assert.Equal(t, timing.ReplaceAllString(stderr.String(), " 0ms "), chain)
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-replay", dir, server.URL + "/tag"}, &stdout, &stderr)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, stdout.String(), resolved)
+	assert.Equal(t, timing.ReplaceAllString(stderr.String(), " 0ms "), chain)
+}
+
+func TestRunFileReplayMissing(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "tag.xml")
+	data := `<VAST version="2.0"><Ad id="1"><Wrapper>` +
+		`<VASTAdTagURI>http://partner.example/vast</VASTAdTagURI>` +
+		`<AdSystem>wrapper</AdSystem>` +
+		`</Wrapper></Ad></VAST>`
+	assert.Nil(t, os.WriteFile(name, []byte(data), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-replay", dir, name}, &stdout, &stderr)
+	assert.Equal(t, code, 1)
+	assert.Equal(t, stdout.String(), "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<VAST version=\"2.0\"></VAST>\n")
+	assert.Equal(t, timing.ReplaceAllString(stderr.String(), " 0ms "), "Ad[0] id=\"1\"\n"+
+		"  #1 404 0ms - http://partner.example/vast\n"+
+		"vastunwrap: Ad[0]: vast2: http://partner.example/vast: unexpected status 404\n")
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, run(nil, &stdout, &stderr), 2)
+	assert.Equal(t, run([]string{"-record", "a", "-replay", "b", "tag.xml"}, &stdout, &stderr), 2)
+}
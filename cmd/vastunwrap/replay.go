@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// responseFile returns the name of the file holding the recorded response
+// for url.
+func responseFile(dir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".xml")
+}
+
+// replayTransport answers requests with the responses recorded by
+// recordTransport, or 404 if there is none.
+type replayTransport struct {
+	dir string
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(responseFile(t.dir, req.URL.String()))
+	if os.IsNotExist(err) {
+		return response(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response(req, http.StatusOK, data), nil
+}
+
+// recordTransport saves the body of every successful response.
+type recordTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (t recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(responseFile(t.dir, req.URL.String()), data, 0o644); err != nil {
+		return nil, fmt.Errorf("record %s: %w", req.URL, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+func response(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/xml"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,248 @@
+package vast2
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ChangeType int
+
+const (
+	ChangeAdded ChangeType = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two documents. Path is the location of the
+// changed element or attribute, e.g. VAST/Ad[id=1]/InLine/AdTitle or
+// VAST/Ad[id=1]/InLine/Creatives/Creative[seq=2]/@AdID.
+// Old and New hold the values of changed fields and are empty for added or
+// removed elements.
+type Change struct {
+	Type ChangeType
+	Path string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		if c.New == "" {
+			return "+ " + c.Path
+		}
+		return fmt.Sprintf("+ %s: %q", c.Path, c.New)
+	case ChangeRemoved:
+		if c.Old == "" {
+			return "- " + c.Path
+		}
+		return fmt.Sprintf("- %s: %q", c.Path, c.Old)
+	}
+	return fmt.Sprintf("~ %s: %q -> %q", c.Path, c.Old, c.New)
+}
+
+// FormatChanges returns the changes in a human readable form, one per line.
+func FormatChanges(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Diff compares two documents and returns the changes turning a into b.
+// Text values are compared without surrounding whitespace, Ads are matched
+// by id and Creatives by id or sequence; all other repeated elements are
+// matched by position.
+func Diff(a, b *VAST) []Change {
+	d := differ{}
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		d.add(ChangeAdded, "VAST", "", "")
+	case b == nil:
+		d.add(ChangeRemoved, "VAST", "", "")
+	default:
+		d.diff("VAST", reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem())
+	}
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(typ ChangeType, path, old, new string) {
+	d.changes = append(d.changes, Change{Type: typ, Path: path, Old: old, New: new})
+}
+
+var (
+	byteSliceType   = reflect.TypeOf([]byte(nil))
+	innerXMLSpaceRe = regexp.MustCompile(`>\s+<`)
+)
+
+// leafValue returns the normalised text of a leaf value and whether v is a
+// leaf at all.
+func leafValue(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()), true
+	case reflect.Int:
+		if v.Int() == 0 {
+			return "", true
+		}
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Bool:
+		if !v.Bool() {
+			return "", true
+		}
+		return "true", true
+	}
+	if v.Type() == byteSliceType {
+		data := strings.TrimSpace(string(v.Bytes()))
+		return innerXMLSpaceRe.ReplaceAllString(data, "><"), true
+	}
+	return "", false
+}
+
+func (d *differ) diff(path string, a, b reflect.Value) {
+	if av, ok := leafValue(a); ok {
+		bv, _ := leafValue(b)
+		switch {
+		case av == bv:
+		case av == "":
+			d.add(ChangeAdded, path, "", bv)
+		case bv == "":
+			d.add(ChangeRemoved, path, av, "")
+		default:
+			d.add(ChangeModified, path, av, bv)
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			d.add(ChangeAdded, path, "", "")
+		case b.IsNil():
+			d.add(ChangeRemoved, path, "", "")
+		default:
+			d.diff(path, a.Elem(), b.Elem())
+		}
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			d.diff(fieldPath(path, t.Field(i)), a.Field(i), b.Field(i))
+		}
+	case reflect.Slice:
+		d.diffSlice(path, a, b)
+	}
+}
+
+func fieldPath(path string, f reflect.StructField) string {
+	tag := f.Tag.Get("xml")
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	switch {
+	case strings.Contains(opts, "chardata"), strings.Contains(opts, "innerxml"):
+		return path
+	case strings.Contains(opts, "attr"):
+		return path + "/@" + name
+	}
+	return path + "/" + name
+}
+
+func (d *differ) diffSlice(path string, a, b reflect.Value) {
+	name := path[strings.LastIndexByte(path, '/')+1:]
+	parent := path[:len(path)-len(name)]
+	ak, aok := sliceKeys(a)
+	bk, bok := sliceKeys(b)
+	if !aok || !bok {
+		ak, bk = indexKeys(a.Len()), indexKeys(b.Len())
+	}
+
+	matched := make(map[string]bool, a.Len())
+	for i := 0; i < a.Len(); i++ {
+		ep := parent + name + "[" + ak[i] + "]"
+		j := indexOf(bk, ak[i])
+		if j < 0 {
+			old, _ := leafValue(a.Index(i))
+			d.add(ChangeRemoved, ep, old, "")
+			continue
+		}
+		matched[ak[i]] = true
+		d.diff(ep, a.Index(i), b.Index(j))
+	}
+	for j := 0; j < b.Len(); j++ {
+		if !matched[bk[j]] {
+			value, _ := leafValue(b.Index(j))
+			d.add(ChangeAdded, parent+name+"["+bk[j]+"]", "", value)
+		}
+	}
+}
+
+// sliceKeys returns the keys used to match the elements of s with the
+// elements of the other document. Ads are keyed by id and Creatives by id or
+// sequence. It reports false if s has no such keys or they are ambiguous.
+func sliceKeys(s reflect.Value) ([]string, bool) {
+	keys := make([]string, s.Len())
+	seen := make(map[string]bool, s.Len())
+	for i := range keys {
+		var key string
+		switch e := s.Index(i).Interface().(type) {
+		case Ad:
+			if id := strings.TrimSpace(e.ID); id != "" {
+				key = "id=" + id
+			}
+		case Creative:
+			if id := strings.TrimSpace(e.ID); id != "" {
+				key = "id=" + id
+			} else if e.Sequence != 0 {
+				key = "seq=" + strconv.Itoa(e.Sequence)
+			}
+		}
+		if key == "" || seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		keys[i] = key
+	}
+	return keys, true
+}
+
+func indexKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	return keys
+}
+
+func indexOf(s []string, v string) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
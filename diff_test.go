@@ -0,0 +1,139 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffEqual(t *testing.T) {
+	assert.Nil(t, Diff(nil, nil))
+	assert.Nil(t, Diff(testCloneVAST(), testCloneVAST()))
+}
+
+func TestDiffNil(t *testing.T) {
+	assert.Equal(t, Diff(nil, &VAST{}), []Change{{Type: ChangeAdded, Path: "VAST"}})
+	assert.Equal(t, Diff(&VAST{}, nil), []Change{{Type: ChangeRemoved, Path: "VAST"}})
+}
+
+func TestDiffIgnoresFormatting(t *testing.T) {
+	a := `<VAST version="2.0"><Ad id="1"><InLine>` +
+		`<AdTitle>Title</AdTitle>` +
+		`<AdSystem version="1.0">pubnative</AdSystem>` +
+		`<Creatives><Creative><Linear><Duration>00:00:30</Duration><MediaFiles>` +
+		`<MediaFile delivery="progressive" type="video/mp4" width="640" height="360">http://media.com/a.mp4</MediaFile>` +
+		`</MediaFiles></Linear></Creative></Creatives>` +
+		`<Extensions><Extension type="pricing"><Price>2.5</Price></Extension></Extensions>` +
+		`</InLine></Ad></VAST>`
+	b := `<VAST version="2.0">
+  <Ad id="1">
+    <InLine>
+      <AdTitle> Title </AdTitle>
+      <AdSystem version="1.0">pubnative</AdSystem>
+      <Creatives>
+        <Creative>
+          <Linear>
+            <Duration>00:00:30</Duration>
+            <MediaFiles>
+              <MediaFile height="360" width="640" type="video/mp4" delivery="progressive">
+                <![CDATA[http://media.com/a.mp4]]>
+              </MediaFile>
+            </MediaFiles>
+          </Linear>
+        </Creative>
+      </Creatives>
+      <Extensions>
+        <Extension type="pricing">
+          <Price>2.5</Price>
+        </Extension>
+      </Extensions>
+    </InLine>
+  </Ad>
+</VAST>`
+
+	var va, vb VAST
+	assert.Nil(t, xml.Unmarshal([]byte(a), &va))
+	assert.Nil(t, xml.Unmarshal([]byte(b), &vb))
+	assert.Nil(t, Diff(&va, &vb))
+}
+
+func TestDiffChanges(t *testing.T) {
+	a := testCloneVAST()
+	b := testCloneVAST()
+
+	b.Ad[0], b.Ad[1] = b.Ad[1], b.Ad[0]
+	inLine := b.Ad[1].InLine
+	inLine.AdTitle = "New Title"
+	inLine.Creatives.Creative[0].Linear.VideoClicks = nil
+	inLine.Creatives.Creative[0].Linear.MediaFiles.MediaFile[0].Width = 1280
+	inLine.Creatives.Creative[1].CompanionAds.Companion[0].ExpandedWidth = 600
+	inLine.Creatives.Creative = append(inLine.Creatives.Creative[:2], Creative{ID: "c4"})
+	b.Ad = append(b.Ad, Ad{ID: "3"})
+
+	changes := Diff(a, b)
+	assert.Equal(t, changes, []Change{
+		{Type: ChangeModified, Path: "VAST/Ad[id=1]/InLine/AdTitle", Old: "Title", New: "New Title"},
+		{Type: ChangeRemoved, Path: "VAST/Ad[id=1]/InLine/Creatives/Creative[id=c1]/Linear/VideoClicks"},
+		{Type: ChangeModified, Path: "VAST/Ad[id=1]/InLine/Creatives/Creative[id=c1]/Linear/MediaFiles/MediaFile[0]/@width", Old: "640", New: "1280"},
+		{Type: ChangeAdded, Path: "VAST/Ad[id=1]/InLine/Creatives/Creative[id=c2]/CompanionAds/Companion[0]/@expandedWidth", New: "600"},
+		{Type: ChangeRemoved, Path: "VAST/Ad[id=1]/InLine/Creatives/Creative[id=c3]"},
+		{Type: ChangeAdded, Path: "VAST/Ad[id=1]/InLine/Creatives/Creative[id=c4]"},
+		{Type: ChangeAdded, Path: "VAST/Ad[id=3]"},
+	})
+
+	res := `~ VAST/Ad[id=1]/InLine/AdTitle: "Title" -> "New Title"` + "\n" +
+		`- VAST/Ad[id=1]/InLine/Creatives/Creative[id=c1]/Linear/VideoClicks` + "\n" +
+		`~ VAST/Ad[id=1]/InLine/Creatives/Creative[id=c1]/Linear/MediaFiles/MediaFile[0]/@width: "640" -> "1280"` + "\n" +
+		`+ VAST/Ad[id=1]/InLine/Creatives/Creative[id=c2]/CompanionAds/Companion[0]/@expandedWidth: "600"` + "\n" +
+		`- VAST/Ad[id=1]/InLine/Creatives/Creative[id=c3]` + "\n" +
+		`+ VAST/Ad[id=1]/InLine/Creatives/Creative[id=c4]` + "\n" +
+		`+ VAST/Ad[id=3]` + "\n"
+	assert.Equal(t, FormatChanges(changes), res)
+}
+
+func TestDiffCreativesBySequence(t *testing.T) {
+	a := &VAST{Ad: []Ad{{InLine: &InLine{Creatives: Creatives{Creative: []Creative{
+		{Sequence: 1, AdID: "a"},
+		{Sequence: 2, AdID: "b"},
+	}}}}}}
+	b := &VAST{Ad: []Ad{{InLine: &InLine{Creatives: Creatives{Creative: []Creative{
+		{Sequence: 2, AdID: "b"},
+		{Sequence: 1, AdID: "c"},
+	}}}}}}
+
+	assert.Equal(t, Diff(a, b), []Change{
+		{Type: ChangeModified, Path: "VAST/Ad[0]/InLine/Creatives/Creative[seq=1]/@AdID", Old: "a", New: "c"},
+	})
+}
+
+func TestDiffExtensionData(t *testing.T) {
+	a := &VAST{Ad: []Ad{{ID: "1", InLine: &InLine{Extensions: &Extensions{Extension: []Extension{
+		{Type: "pricing", Data: []byte("<Price>2.5</Price>")},
+	}}}}}}
+	b := a.Clone()
+	b.Ad[0].InLine.Extensions.Extension[0].Data = []byte("<Price>3</Price>")
+
+	assert.Equal(t, Diff(a, b), []Change{
+		{Type: ChangeModified, Path: "VAST/Ad[id=1]/InLine/Extensions/Extension[0]", Old: "<Price>2.5</Price>", New: "<Price>3</Price>"},
+	})
+}
+
+func TestDiffStringSlice(t *testing.T) {
+	a := &VAST{Ad: []Ad{{ID: "1", InLine: &InLine{Error: []string{"http://err.com/a", "http://err.com/b"}}}}}
+	b := a.Clone()
+	b.Ad[0].InLine.Error = []string{"http://err.com/a"}
+	assert.Equal(t, Diff(a, b), []Change{
+		{Type: ChangeRemoved, Path: "VAST/Ad[id=1]/InLine/Error[1]", Old: "http://err.com/b"},
+	})
+	assert.Equal(t, Diff(b, a), []Change{
+		{Type: ChangeAdded, Path: "VAST/Ad[id=1]/InLine/Error[1]", New: "http://err.com/b"},
+	})
+}
+
+func TestChangeTypeString(t *testing.T) {
+	assert.Equal(t, ChangeAdded.String(), "added")
+	assert.Equal(t, ChangeRemoved.String(), "removed")
+	assert.Equal(t, ChangeModified.String(), "modified")
+	assert.Equal(t, ChangeType(10).String(), "unknown")
+}
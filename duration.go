@@ -0,0 +1,70 @@
+package vast2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses a Linear Duration in the HH:MM:SS or HH:MM:SS.mmm
+// format.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("vast2: invalid duration %q", s)
+	}
+
+	sec, msec, hasMsec := strings.Cut(parts[2], ".")
+	fields := []string{parts[0], parts[1], sec}
+	if hasMsec {
+		fields = append(fields, msec)
+	}
+	var values [4]int
+	for i, f := range fields {
+		maxLen := 2
+		if i == 3 {
+			maxLen = 3
+		}
+		if f == "" || len(f) > maxLen {
+			return 0, fmt.Errorf("vast2: invalid duration %q", s)
+		}
+		for _, c := range f {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("vast2: invalid duration %q", s)
+			}
+		}
+		values[i], _ = strconv.Atoi(f)
+	}
+	if values[1] > 59 || values[2] > 59 {
+		return 0, fmt.Errorf("vast2: invalid duration %q", s)
+	}
+	if hasMsec {
+		for i := len(msec); i < 3; i++ {
+			values[3] *= 10
+		}
+	}
+
+	return time.Duration(values[0])*time.Hour +
+		time.Duration(values[1])*time.Minute +
+		time.Duration(values[2])*time.Second +
+		time.Duration(values[3])*time.Millisecond, nil
+}
+
+// FormatDuration formats d in the HH:MM:SS format, adding milliseconds only
+// when d is not a whole number of seconds.
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Millisecond)
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	ms := d % time.Second / time.Millisecond
+	if ms == 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
@@ -0,0 +1,53 @@
+package vast2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"00:00:30":      30 * time.Second,
+		" 00:00:30 ":    30 * time.Second,
+		"0:01:05":       time.Minute + 5*time.Second,
+		"01:00:00":      time.Hour,
+		"00:00:15.5":    15*time.Second + 500*time.Millisecond,
+		"00:00:15.250":  15*time.Second + 250*time.Millisecond,
+		"10:59:59.999":  10*time.Hour + 59*time.Minute + 59*time.Second + 999*time.Millisecond,
+		"00:00:15.05":   15*time.Second + 50*time.Millisecond,
+		"00:00:00":      0,
+		"00:00:01.0":    time.Second,
+		"00:00:01.01":   time.Second + 10*time.Millisecond,
+		"00:00:09.0009": -1,
+		"":              -1,
+		"30":            -1,
+		"00:30":         -1,
+		"00:00:60":      -1,
+		"00:60:00":      -1,
+		"00:00:3a":      -1,
+		"00:00:-1":      -1,
+		"000:00:01":     -1,
+		"00:00:01.":     -1,
+		"00:00:01:00":   -1,
+	}
+	for s, expected := range tests {
+		d, err := ParseDuration(s)
+		if expected < 0 {
+			assert.NotNil(t, err, s)
+			continue
+		}
+		assert.Nil(t, err, s)
+		assert.Equal(t, d, expected, s)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, FormatDuration(0), "00:00:00")
+	assert.Equal(t, FormatDuration(-time.Second), "00:00:00")
+	assert.Equal(t, FormatDuration(30*time.Second), "00:00:30")
+	assert.Equal(t, FormatDuration(time.Hour+2*time.Minute+3*time.Second), "01:02:03")
+	assert.Equal(t, FormatDuration(15*time.Second+500*time.Millisecond), "00:00:15.500")
+	assert.Equal(t, FormatDuration(15*time.Second+400*time.Microsecond), "00:00:15")
+}
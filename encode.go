@@ -0,0 +1,488 @@
+package vast2
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// AppendXML appends the encoding of v to b and returns the extended buffer.
+// The output is identical to the one of xml.Marshal without its reflection
+// and allocation cost. Like xml.Marshal, it fails with ErrAdChoice or
+// ErrCreativeChoice if v holds an Ad or Creative with an ambiguous Kind, in
+// which case b is returned unchanged.
+func (v *VAST) AppendXML(b []byte) ([]byte, error) {
+	e := encoder{b: b}
+	e.vast(v)
+	if e.err != nil {
+		return b, e.err
+	}
+	return e.b, nil
+}
+
+var encodeBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// WriteTo writes the encoding of v, as returned by AppendXML, to w. Nothing
+// is written if v holds an Ad or Creative with an ambiguous Kind, for which
+// ErrAdChoice or ErrCreativeChoice is returned.
+func (v *VAST) WriteTo(w io.Writer) (int64, error) {
+	bp := encodeBufPool.Get().(*[]byte)
+	defer encodeBufPool.Put(bp)
+	e := encoder{b: (*bp)[:0]}
+	e.vast(v)
+	*bp = e.b
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := w.Write(e.b)
+	return int64(n), err
+}
+
+// encoder writes the XML encoding of the schema types. With cdata set the
+// text of cdataElements is written in CDATA sections as done by Format.
+// err records the first Ad or Creative with an ambiguous Kind.
+type encoder struct {
+	b     []byte
+	cdata bool
+	err   error
+}
+
+func (e *encoder) escape(s string) {
+	last := 0
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		i += width
+		var esc string
+		switch r {
+		case '"':
+			esc = "&#34;"
+		case '\'':
+			esc = "&#39;"
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			esc = "&gt;"
+		case '\t':
+			esc = "&#x9;"
+		case '\n':
+			esc = "&#xA;"
+		case '\r':
+			esc = "&#xD;"
+		default:
+			if isXMLChar(r) && !(r == utf8.RuneError && width == 1) {
+				continue
+			}
+			esc = "\uFFFD"
+		}
+		e.b = append(e.b, s[last:i-width]...)
+		e.b = append(e.b, esc...)
+		last = i
+	}
+	e.b = append(e.b, s[last:]...)
+}
+
+// writeCDATA writes s as a CDATA section, replacing the characters which are
+// not allowed in XML and splitting the section at "]]>".
+func (e *encoder) writeCDATA(s string) {
+	e.b = append(e.b, "<![CDATA["...)
+	last := 0
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		i += width
+		switch {
+		case r == '>' && i >= 3 && s[i-3:i] == "]]>":
+			e.b = append(e.b, s[last:i-1]...)
+			e.b = append(e.b, "]]><![CDATA[>"...)
+		case !isXMLChar(r) || (r == utf8.RuneError && width == 1):
+			e.b = append(e.b, s[last:i-width]...)
+			e.b = append(e.b, "\uFFFD"...)
+		default:
+			continue
+		}
+		last = i
+	}
+	e.b = append(e.b, s[last:]...)
+	e.b = append(e.b, "]]>"...)
+}
+
+// isXMLChar reports whether r is in the XML character range.
+func isXMLChar(r rune) bool {
+	return r == 0x09 ||
+		r == 0x0A ||
+		r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+func (e *encoder) open(name string) {
+	e.b = append(e.b, '<')
+	e.b = append(e.b, name...)
+}
+
+func (e *encoder) openEnd() {
+	e.b = append(e.b, '>')
+}
+
+func (e *encoder) close(name string) {
+	e.b = append(e.b, "</"...)
+	e.b = append(e.b, name...)
+	e.b = append(e.b, '>')
+}
+
+func (e *encoder) attr(name, value string) {
+	e.b = append(e.b, ' ')
+	e.b = append(e.b, name...)
+	e.b = append(e.b, `="`...)
+	e.escape(value)
+	e.b = append(e.b, '"')
+}
+
+func (e *encoder) attrOmitEmpty(name, value string) {
+	if value != "" {
+		e.attr(name, value)
+	}
+}
+
+func (e *encoder) attrInt(name string, value int) {
+	e.b = append(e.b, ' ')
+	e.b = append(e.b, name...)
+	e.b = append(e.b, `="`...)
+	e.b = strconv.AppendInt(e.b, int64(value), 10)
+	e.b = append(e.b, '"')
+}
+
+func (e *encoder) attrIntOmitEmpty(name string, value int) {
+	if value != 0 {
+		e.attrInt(name, value)
+	}
+}
+
+func (e *encoder) attrBoolOmitEmpty(name string, value bool) {
+	if value {
+		e.b = append(e.b, ' ')
+		e.b = append(e.b, name...)
+		e.b = append(e.b, `="true"`...)
+	}
+}
+
+func (e *encoder) text(name, s string) {
+	if s == "" {
+		return
+	}
+	if e.cdata && cdataElements[name] {
+		e.writeCDATA(s)
+		return
+	}
+	e.escape(s)
+}
+
+// element writes <name>s</name>.
+func (e *encoder) element(name, s string) {
+	e.open(name)
+	e.openEnd()
+	e.text(name, s)
+	e.close(name)
+}
+
+func (e *encoder) elementOmitEmpty(name, s string) {
+	if s != "" {
+		e.element(name, s)
+	}
+}
+
+func (e *encoder) vast(v *VAST) {
+	if v == nil {
+		return
+	}
+	e.open("VAST")
+	e.attr("version", v.version())
+	e.openEnd()
+	for i := range v.Ad {
+		e.ad(&v.Ad[i])
+	}
+	e.close("VAST")
+}
+
+func (e *encoder) ad(ad *Ad) {
+	if e.err == nil && ad.Kind() == AdAmbiguous {
+		e.err = ErrAdChoice
+	}
+	e.open("Ad")
+	e.attr("id", ad.ID)
+	e.openEnd()
+	if ad.InLine != nil {
+		e.inLine(ad.InLine)
+	}
+	if ad.Wrapper != nil {
+		e.wrapper(ad.Wrapper)
+	}
+	e.close("Ad")
+}
+
+func (e *encoder) inLine(inLine *InLine) {
+	e.open("InLine")
+	e.openEnd()
+	e.element("AdTitle", inLine.AdTitle)
+	e.elementOmitEmpty("Description", inLine.Description)
+	e.elementOmitEmpty("Survey", inLine.Survey)
+	e.errors(inLine.Error)
+	e.adSystem(&inLine.AdSystem)
+	e.impressions(inLine.Impression)
+	e.creatives(&inLine.Creatives)
+	e.extensions(inLine.Extensions)
+	e.close("InLine")
+}
+
+func (e *encoder) wrapper(wrapper *Wrapper) {
+	e.open("Wrapper")
+	e.openEnd()
+	e.element("VASTAdTagURI", wrapper.VASTAdTagURI)
+	e.errors(wrapper.Error)
+	e.adSystem(&wrapper.AdSystem)
+	e.impressions(wrapper.Impression)
+	e.creatives(&wrapper.Creatives)
+	e.extensions(wrapper.Extensions)
+	e.close("Wrapper")
+}
+
+func (e *encoder) errors(urls []string) {
+	for _, url := range urls {
+		e.elementOmitEmpty("Error", url)
+	}
+}
+
+func (e *encoder) adSystem(adSystem *AdSystem) {
+	e.open("AdSystem")
+	e.attrOmitEmpty("version", adSystem.Version)
+	e.openEnd()
+	e.text("AdSystem", adSystem.Data)
+	e.close("AdSystem")
+}
+
+func (e *encoder) impressions(imps []Impression) {
+	for i := range imps {
+		e.open("Impression")
+		e.attrOmitEmpty("id", imps[i].ID)
+		e.openEnd()
+		e.text("Impression", imps[i].Data)
+		e.close("Impression")
+	}
+}
+
+func (e *encoder) creatives(creatives *Creatives) {
+	e.open("Creatives")
+	e.openEnd()
+	for i := range creatives.Creative {
+		e.creative(&creatives.Creative[i])
+	}
+	e.close("Creatives")
+}
+
+func (e *encoder) creative(creative *Creative) {
+	if e.err == nil && creative.Kind() == CreativeAmbiguous {
+		e.err = ErrCreativeChoice
+	}
+	e.open("Creative")
+	e.attrOmitEmpty("id", creative.ID)
+	e.attrIntOmitEmpty("sequence", creative.Sequence)
+	e.attrOmitEmpty("AdID", creative.AdID)
+	e.openEnd()
+	if creative.Linear != nil {
+		e.linear(creative.Linear)
+	}
+	if creative.CompanionAds != nil {
+		e.companionAds(creative.CompanionAds)
+	}
+	if creative.NonLinearAds != nil {
+		e.nonLinearAds(creative.NonLinearAds)
+	}
+	e.close("Creative")
+}
+
+func (e *encoder) linear(linear *Linear) {
+	e.open("Linear")
+	e.openEnd()
+	e.element("Duration", linear.Duration)
+	e.elementOmitEmpty("AdParameters", linear.AdParameters)
+	if linear.TrackingEvents != nil {
+		e.trackingEvents(linear.TrackingEvents)
+	}
+	if linear.VideoClicks != nil {
+		e.videoClicks(linear.VideoClicks)
+	}
+	e.open("MediaFiles")
+	e.openEnd()
+	for i := range linear.MediaFiles.MediaFile {
+		e.mediaFile(&linear.MediaFiles.MediaFile[i])
+	}
+	e.close("MediaFiles")
+	e.creativeExtensions(linear.CreativeExtensions)
+	e.close("Linear")
+}
+
+func (e *encoder) trackingEvents(events *TrackingEvents) {
+	e.open("TrackingEvents")
+	e.openEnd()
+	for i := range events.Tracking {
+		e.open("Tracking")
+		e.attr("event", events.Tracking[i].Event)
+		e.openEnd()
+		e.text("Tracking", events.Tracking[i].Data)
+		e.close("Tracking")
+	}
+	e.close("TrackingEvents")
+}
+
+func (e *encoder) videoClicks(clicks *VideoClicks) {
+	e.open("VideoClicks")
+	e.openEnd()
+	e.elementOmitEmpty("ClickThrough", clicks.ClickThrough)
+	for i := range clicks.ClickTracking {
+		e.open("ClickTracking")
+		e.attrOmitEmpty("id", clicks.ClickTracking[i].ID)
+		e.openEnd()
+		e.text("ClickTracking", clicks.ClickTracking[i].Data)
+		e.close("ClickTracking")
+	}
+	for i := range clicks.CustomClick {
+		e.open("CustomClick")
+		e.attrOmitEmpty("id", clicks.CustomClick[i].ID)
+		e.openEnd()
+		e.text("CustomClick", clicks.CustomClick[i].Data)
+		e.close("CustomClick")
+	}
+	e.close("VideoClicks")
+}
+
+func (e *encoder) mediaFile(mediaFile *MediaFile) {
+	e.open("MediaFile")
+	e.attrOmitEmpty("id", mediaFile.ID)
+	e.attr("delivery", mediaFile.Delivery)
+	e.attr("type", mediaFile.Type)
+	e.attrIntOmitEmpty("bitrate", mediaFile.Bitrate)
+	e.attrInt("width", mediaFile.Width)
+	e.attrInt("height", mediaFile.Height)
+	e.attrBoolOmitEmpty("scalable", mediaFile.Scalable)
+	e.attrBoolOmitEmpty("maintainAspectRatio", mediaFile.MaintainAspectRatio)
+	e.attrOmitEmpty("apiFramework", mediaFile.ApiFramework)
+	e.openEnd()
+	e.text("MediaFile", mediaFile.Data)
+	e.close("MediaFile")
+}
+
+func (e *encoder) companionAds(companionAds *CompanionAds) {
+	e.open("CompanionAds")
+	e.openEnd()
+	for i := range companionAds.Companion {
+		e.companion(&companionAds.Companion[i])
+	}
+	e.close("CompanionAds")
+}
+
+func (e *encoder) companion(companion *Companion) {
+	e.open("Companion")
+	e.attrOmitEmpty("id", companion.ID)
+	e.attrInt("width", companion.Width)
+	e.attrInt("height", companion.Height)
+	e.attrIntOmitEmpty("expandedWidth", companion.ExpandedWidth)
+	e.attrIntOmitEmpty("expandedHeight", companion.ExpandedHeight)
+	e.attrOmitEmpty("apiFramework", companion.ApiFramework)
+	e.openEnd()
+	e.elementOmitEmpty("IFrameResource", companion.IFrameResource)
+	e.elementOmitEmpty("HTMLResource", companion.HTMLResource)
+	e.elementOmitEmpty("CompanionClickThrough", companion.CompanionClickThrough)
+	e.elementOmitEmpty("AltText", companion.AltText)
+	e.elementOmitEmpty("AdParameters", companion.AdParameters)
+	if companion.StaticResource != nil {
+		e.staticResource(companion.StaticResource)
+	}
+	if companion.TrackingEvents != nil {
+		e.trackingEvents(companion.TrackingEvents)
+	}
+	e.creativeExtensions(companion.CreativeExtensions)
+	e.close("Companion")
+}
+
+func (e *encoder) staticResource(res *StaticResource) {
+	e.open("StaticResource")
+	e.attr("creativeType", res.CreativeType)
+	e.openEnd()
+	e.text("StaticResource", res.Data)
+	e.close("StaticResource")
+}
+
+func (e *encoder) nonLinearAds(nonLinearAds *NonLinearAds) {
+	e.open("NonLinearAds")
+	e.openEnd()
+	for i := range nonLinearAds.NonLinear {
+		e.nonLinear(&nonLinearAds.NonLinear[i])
+	}
+	if nonLinearAds.TrackingEvents != nil {
+		e.trackingEvents(nonLinearAds.TrackingEvents)
+	}
+	e.close("NonLinearAds")
+}
+
+func (e *encoder) nonLinear(nonLinear *NonLinear) {
+	e.open("NonLinear")
+	e.attrOmitEmpty("id", nonLinear.ID)
+	e.attrInt("width", nonLinear.Width)
+	e.attrInt("height", nonLinear.Height)
+	e.attrIntOmitEmpty("expandedWidth", nonLinear.ExpandedWidth)
+	e.attrIntOmitEmpty("expandedHeight", nonLinear.ExpandedHeight)
+	e.attrBoolOmitEmpty("scalable", nonLinear.Scalable)
+	e.attrBoolOmitEmpty("maintainAspectRatio", nonLinear.MaintainAspectRatio)
+	e.attrOmitEmpty("apiFramework", nonLinear.ApiFramework)
+	e.attrOmitEmpty("minSuggestedDuration", nonLinear.MinSuggestedDuration)
+	e.openEnd()
+	e.elementOmitEmpty("IFrameResource", nonLinear.IFrameResource)
+	e.elementOmitEmpty("HTMLResource", nonLinear.HTMLResource)
+	e.elementOmitEmpty("AdParameters", nonLinear.AdParameters)
+	e.elementOmitEmpty("NonLinearClickThrough", nonLinear.NonLinearClickThrough)
+	if nonLinear.StaticResource != nil {
+		e.staticResource(nonLinear.StaticResource)
+	}
+	e.creativeExtensions(nonLinear.CreativeExtensions)
+	e.close("NonLinear")
+}
+
+func (e *encoder) extensions(exts *Extensions) {
+	if exts == nil {
+		return
+	}
+	e.open("Extensions")
+	e.openEnd()
+	for i := range exts.Extension {
+		e.open("Extension")
+		e.attrOmitEmpty("type", exts.Extension[i].Type)
+		e.openEnd()
+		e.b = append(e.b, exts.Extension[i].Data...)
+		e.close("Extension")
+	}
+	e.close("Extensions")
+}
+
+func (e *encoder) creativeExtensions(exts *CreativeExtensions) {
+	if exts == nil {
+		return
+	}
+	e.open("CreativeExtensions")
+	e.openEnd()
+	for i := range exts.CreativeExtension {
+		e.open("CreativeExtension")
+		e.attrOmitEmpty("type", exts.CreativeExtension[i].Type)
+		e.openEnd()
+		e.b = append(e.b, exts.CreativeExtension[i].Data...)
+		e.close("CreativeExtension")
+	}
+	e.close("CreativeExtensions")
+}
@@ -0,0 +1,144 @@
+package vast2
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mustAppendXML returns the encoding of v appended to b, failing t on error.
+func mustAppendXML(t testing.TB, v *VAST, b []byte) []byte {
+	t.Helper()
+	b, err := v.AppendXML(b)
+	if err != nil {
+		t.Fatalf("AppendXML: %v", err)
+	}
+	return b
+}
+
+func TestAppendXMLNil(t *testing.T) {
+	var vast *VAST
+	assert.Equal(t, string(mustAppendXML(t, vast, []byte("x"))), "x")
+}
+
+func TestAppendXMLEqualsMarshal(t *testing.T) {
+	for _, vast := range []*VAST{{}, testCloneVAST(), testValidVAST(), testWalkVAST()} {
+		res, err := xml.Marshal(vast)
+		assert.Nil(t, err)
+		assert.Equal(t, string(mustAppendXML(t, vast, nil)), string(res))
+	}
+}
+
+func TestAppendXMLEscaping(t *testing.T) {
+	vast := &VAST{
+		Version: "2.0\"'<>&\t\n\r",
+		Ad: []Ad{{
+			ID: "\x00\xff�\U0001F600",
+			InLine: &InLine{
+				AdTitle:  "a\x01b\x7fc￾d",
+				AdSystem: AdSystem{Data: "é]]>€\xe2\x82"},
+			},
+		}},
+	}
+	res, err := xml.Marshal(vast)
+	assert.Nil(t, err)
+	assert.Equal(t, string(mustAppendXML(t, vast, nil)), string(res))
+}
+
+func TestAppendXMLAmbiguous(t *testing.T) {
+	vast := &VAST{Version: "2.0", Ad: []Ad{{ID: "1", InLine: &InLine{}, Wrapper: &Wrapper{}}}}
+	b, err := vast.AppendXML([]byte("x"))
+	assert.Equal(t, err, ErrAdChoice)
+	assert.Equal(t, string(b), "x")
+
+	vast = New(NewInLineAd("1", &InLine{Creatives: Creatives{Creative: []Creative{
+		{Linear: &Linear{}, CompanionAds: &CompanionAds{}},
+	}}}))
+	_, err = vast.AppendXML(nil)
+	assert.Equal(t, err, ErrCreativeChoice)
+}
+
+func TestAppendXMLRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 500; i++ {
+		vast := randomVAST(r)
+		res, err := xml.Marshal(vast)
+		assert.Nil(t, err)
+		if !assert.Equal(t, string(mustAppendXML(t, vast, nil)), string(res)) {
+			return
+		}
+	}
+}
+
+func TestFormatCDATAEqualsReformat(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	vasts := []*VAST{{
+		Ad: []Ad{{Wrapper: &Wrapper{
+			VASTAdTagURI: "http://a.com/]]>]]]>\x00\xff",
+			Error:        []string{"\r\n\t", ""},
+		}}},
+	}}
+	for i := 0; i < 500; i++ {
+		vasts = append(vasts, randomVAST(r))
+	}
+
+	opts := FormatOptions{CDATA: true}
+	for _, vast := range vasts {
+		data, err := Format(vast, opts)
+		assert.Nil(t, err)
+		res, err := reformat(mustAppendXML(t, vast, nil), opts)
+		assert.Nil(t, err)
+		if !assert.Equal(t, string(data), string(res)) {
+			return
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	vast := testCloneVAST()
+	var buf bytes.Buffer
+	n, err := vast.WriteTo(&buf)
+	assert.Nil(t, err)
+
+	res, err := xml.Marshal(vast)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), string(res))
+	assert.Equal(t, n, int64(len(res)))
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	vast := testCloneVAST()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := xml.Marshal(vast); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendXML(b *testing.B) {
+	vast := testCloneVAST()
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = vast.AppendXML(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	vast := testCloneVAST()
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if _, err := vast.WriteTo(&buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package vast2
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrExtensionNotRegistered = errors.New("vast2: extension type is not registered")
+	ErrExtensionAttr          = errors.New("vast2: extension values cannot have attributes")
+)
+
+var (
+	extensionTypesMu sync.RWMutex
+	extensionTypes   = map[string]reflect.Type{}
+)
+
+// RegisterExtension associates the Extension type attribute typ with the Go
+// type of v. The fields of v describe the content of the <Extension> element,
+// e.g. for <Extension type="pricing"><Price>2.5</Price></Extension>:
+//
+//	type Pricing struct {
+//		Price float64 `xml:"Price"`
+//	}
+//	vast2.RegisterExtension("pricing", Pricing{})
+func RegisterExtension(typ string, v interface{}) {
+	if typ == "" {
+		panic("vast2: RegisterExtension with empty type")
+	}
+	if v == nil {
+		panic("vast2: RegisterExtension with nil value")
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	extensionTypesMu.Lock()
+	extensionTypes[typ] = t
+	extensionTypesMu.Unlock()
+}
+
+func registeredExtension(typ string) (reflect.Type, bool) {
+	extensionTypesMu.RLock()
+	t, ok := extensionTypes[typ]
+	extensionTypesMu.RUnlock()
+	return t, ok
+}
+
+// NewExtension encodes v as the content of an Extension of the given type.
+// The attributes of an Extension are fixed by the schema, so
+// ErrExtensionAttr is returned if v encodes any.
+func NewExtension(typ string, v interface{}) (Extension, error) {
+	var buf bytes.Buffer
+	start := xml.StartElement{Name: xml.Name{Local: "Extension"}}
+	if err := xml.NewEncoder(&buf).EncodeElement(v, start); err != nil {
+		return Extension{}, err
+	}
+	if buf.Len() == 0 {
+		return Extension{Type: typ}, nil
+	}
+	data, err := innerXML(buf.Bytes())
+	if err != nil {
+		return Extension{}, err
+	}
+	return Extension{Type: typ, Data: data}, nil
+}
+
+// innerXML returns the content of the element encoded in data.
+func innerXML(data []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	tok, err := d.RawToken()
+	if err != nil {
+		return nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, fmt.Errorf("vast2: unexpected extension token %T", tok)
+	}
+	if len(start.Attr) > 0 {
+		return nil, ErrExtensionAttr
+	}
+	begin := d.InputOffset()
+	end := begin
+	for depth := 1; depth > 0; {
+		off := d.InputOffset()
+		tok, err := d.RawToken()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+			end = off
+		}
+	}
+	return data[begin:end], nil
+}
+
+// Decode unmarshals the content of the extension into v. If v has an
+// XMLName field with a name, the content is decoded as an element of that
+// name.
+func (e Extension) Decode(v interface{}) error {
+	data := make([]byte, 0, len(e.Data)+len("<Extension></Extension>"))
+	data = append(data, "<Extension>"...)
+	data = append(data, e.Data...)
+	data = append(data, "</Extension>"...)
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	start := tok.(xml.StartElement)
+	if name, ok := xmlName(v); ok {
+		start.Name = name
+	}
+	return d.DecodeElement(v, &start)
+}
+
+// xmlName returns the name in the tag of the XMLName field of the struct v
+// points to, if any.
+func xmlName(v interface{}) (xml.Name, bool) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return xml.Name{}, false
+	}
+	f, ok := t.FieldByName("XMLName")
+	if !ok || f.Type != reflect.TypeOf(xml.Name{}) {
+		return xml.Name{}, false
+	}
+	tag, _, _ := strings.Cut(f.Tag.Get("xml"), ",")
+	if tag == "" {
+		return xml.Name{}, false
+	}
+	var name xml.Name
+	if i := strings.LastIndexByte(tag, ' '); i >= 0 {
+		name.Space, tag = tag[:i], tag[i+1:]
+	}
+	name.Local = tag
+	return name, true
+}
+
+// Value decodes the extension into a new value of the type registered for
+// e.Type and returns a pointer to it. ErrExtensionNotRegistered is returned
+// for unknown types, whose content stays available in e.Data.
+func (e Extension) Value() (interface{}, error) {
+	t, ok := registeredExtension(e.Type)
+	if !ok {
+		return nil, ErrExtensionNotRegistered
+	}
+	v := reflect.New(t).Interface()
+	if err := e.Decode(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Find returns the first extension with the given type or nil.
+func (e *Extensions) Find(typ string) *Extension {
+	if e == nil {
+		return nil
+	}
+	for i := range e.Extension {
+		if e.Extension[i].Type == typ {
+			return &e.Extension[i]
+		}
+	}
+	return nil
+}
+
+// Set encodes v as the extension of the given type, replacing the first
+// existing extension with that type or appending a new one.
+func (e *Extensions) Set(typ string, v interface{}) error {
+	ext, err := NewExtension(typ, v)
+	if err != nil {
+		return err
+	}
+	if found := e.Find(typ); found != nil {
+		*found = ext
+		return nil
+	}
+	e.Extension = append(e.Extension, ext)
+	return nil
+}
@@ -0,0 +1,155 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPricing struct {
+	Price float64 `xml:"Price"`
+}
+
+func init() {
+	RegisterExtension("pricing", testPricing{})
+}
+
+func TestExtensionDecode(t *testing.T) {
+	ext := Extension{Type: "pricing", Data: []byte(`<Price>2.5</Price>`)}
+
+	var p testPricing
+	assert.Nil(t, ext.Decode(&p))
+	assert.Equal(t, p, testPricing{Price: 2.5})
+}
+
+func TestExtensionValue(t *testing.T) {
+	ext := Extension{Type: "pricing", Data: []byte(`<Price>2.5</Price>`)}
+	v, err := ext.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, v, &testPricing{Price: 2.5})
+}
+
+func TestExtensionValueNotRegistered(t *testing.T) {
+	ext := Extension{Type: "unknown", Data: []byte(`<Foo>bar</Foo>`)}
+	v, err := ext.Value()
+	assert.Nil(t, v)
+	assert.Equal(t, err, ErrExtensionNotRegistered)
+	assert.Equal(t, string(ext.Data), `<Foo>bar</Foo>`)
+}
+
+func TestNewExtension(t *testing.T) {
+	ext, err := NewExtension("pricing", testPricing{Price: 1.25})
+	assert.Nil(t, err)
+	assert.Equal(t, ext.Type, "pricing")
+	assert.Equal(t, string(ext.Data), `<Price>1.25</Price>`)
+
+	data, err := xml.Marshal(ext)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<Extension type="pricing"><Price>1.25</Price></Extension>`)
+}
+
+func TestExtensionsFind(t *testing.T) {
+	exts := &Extensions{
+		Extension: []Extension{
+			{Type: "model", Data: []byte("<Model>cpm</Model>")},
+			{Type: "pricing", Data: []byte("<Price>2.5</Price>")},
+		},
+	}
+	assert.Equal(t, exts.Find("pricing"), &exts.Extension[1])
+	assert.Nil(t, exts.Find("waterfall"))
+
+	var nilExts *Extensions
+	assert.Nil(t, nilExts.Find("pricing"))
+}
+
+func TestExtensionsSet(t *testing.T) {
+	exts := &Extensions{
+		Extension: []Extension{
+			{Type: "pricing", Data: []byte("<Price>2.5</Price>")},
+		},
+	}
+	assert.Nil(t, exts.Set("pricing", testPricing{Price: 3}))
+	assert.Nil(t, exts.Set("model", struct {
+		Model string `xml:"Model"`
+	}{"cpm"}))
+
+	data, err := xml.Marshal(exts)
+	assert.Nil(t, err)
+
+	res := `<Extensions>` +
+		`<Extension type="pricing"><Price>3</Price></Extension>` +
+		`<Extension type="model"><Model>cpm</Model></Extension>` +
+		`</Extensions>`
+	assert.Equal(t, string(data), res)
+}
+
+func TestExtensionsRoundTrip(t *testing.T) {
+	src := `<Extensions>` +
+		`<Extension type="pricing"><Price>2.5</Price></Extension>` +
+		`<Extension type="other"><Foo>bar</Foo></Extension>` +
+		`</Extensions>`
+
+	var exts Extensions
+	assert.Nil(t, xml.Unmarshal([]byte(src), &exts))
+
+	v, err := exts.Find("pricing").Value()
+	assert.Nil(t, err)
+	p := v.(*testPricing)
+	p.Price = 4
+	assert.Nil(t, exts.Set("pricing", p))
+
+	data, err := xml.Marshal(exts)
+	assert.Nil(t, err)
+
+	res := `<Extensions>` +
+		`<Extension type="pricing"><Price>4</Price></Extension>` +
+		`<Extension type="other"><Foo>bar</Foo></Extension>` +
+		`</Extensions>`
+	assert.Equal(t, string(data), res)
+}
+
+type testNamedPricing struct {
+	XMLName xml.Name `xml:"Pricing"`
+	Price   float64  `xml:"Price"`
+}
+
+type testAttrPricing struct {
+	Kind  string  `xml:"kind,attr"`
+	Price float64 `xml:"Price"`
+}
+
+func TestNewExtensionXMLName(t *testing.T) {
+	ext, err := NewExtension("pricing", testNamedPricing{Price: 1.25})
+	assert.Nil(t, err)
+	assert.Equal(t, string(ext.Data), `<Price>1.25</Price>`)
+
+	var p testNamedPricing
+	assert.Nil(t, ext.Decode(&p))
+	assert.Equal(t, p.Price, 1.25)
+
+	ext, err = NewExtension("pricing", &testPricing{Price: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, string(ext.Data), `<Price>1</Price>`)
+
+	ext, err = NewExtension("empty", struct{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, string(ext.Data), ``)
+}
+
+func TestNewExtensionAttr(t *testing.T) {
+	_, err := NewExtension("pricing", testAttrPricing{Kind: "k", Price: 1})
+	assert.Equal(t, err, ErrExtensionAttr)
+
+	exts := &Extensions{}
+	assert.Equal(t, exts.Set("pricing", testAttrPricing{Kind: "k"}), ErrExtensionAttr)
+	assert.Empty(t, exts.Extension)
+}
+
+func TestExtensionValueXMLName(t *testing.T) {
+	RegisterExtension("named-pricing", testNamedPricing{})
+	ext := Extension{Type: "named-pricing", Data: []byte(`<Price>2.5</Price>`)}
+	v, err := ext.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, v.(*testNamedPricing).Price, 2.5)
+}
@@ -0,0 +1,220 @@
+package vast2
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Canonicalize normalises v in place so that equivalent documents encode to
+// the same bytes: URLs are trimmed and valid Durations are rewritten in the
+// HH:MM:SS(.mmm) format. Element order and attribute values are normalised
+// by the encoding itself.
+func Canonicalize(v *VAST) {
+	Walk(v, Visitor{
+		Linear: func(p Path, linear *Linear) WalkAction {
+			if d, err := ParseDuration(linear.Duration); err == nil {
+				linear.Duration = FormatDuration(d)
+			}
+			return WalkContinue
+		},
+		URL: func(p Path, kind URLKind, url *string) {
+			*url = strings.TrimSpace(*url)
+		},
+	})
+}
+
+type FormatOptions struct {
+	// Indent is repeated once per nesting level to pretty-print the
+	// document. The document is minified if it is empty.
+	Indent string
+	// CDATA wraps URLs, HTMLResource and AdParameters in CDATA sections.
+	CDATA bool
+}
+
+// cdataElements are the elements whose text is wrapped in a CDATA section
+// with FormatOptions.CDATA.
+var cdataElements = map[string]bool{
+	"Impression":            true,
+	"Tracking":              true,
+	"ClickThrough":          true,
+	"ClickTracking":         true,
+	"CustomClick":           true,
+	"Error":                 true,
+	"Survey":                true,
+	"MediaFile":             true,
+	"StaticResource":        true,
+	"IFrameResource":        true,
+	"HTMLResource":          true,
+	"AdParameters":          true,
+	"CompanionClickThrough": true,
+	"NonLinearClickThrough": true,
+	"VASTAdTagURI":          true,
+}
+
+// Format encodes v with the given options. The content of Extensions and
+// CreativeExtensions is copied as is apart from indentation. Like
+// xml.Marshal, Format fails with ErrAdChoice or ErrCreativeChoice on Ads and
+// Creatives with an ambiguous Kind.
+func Format(v *VAST, opts FormatOptions) ([]byte, error) {
+	if opts.Indent == "" {
+		e := encoder{cdata: opts.CDATA}
+		e.vast(v)
+		if e.err != nil {
+			return nil, e.err
+		}
+		return e.b, nil
+	}
+	e := encoder{}
+	e.vast(v)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return reformat(e.b, opts)
+}
+
+// reformat indents the encoded document data.
+func reformat(data []byte, opts FormatOptions) ([]byte, error) {
+	f := formatter{opts: opts}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			return f.buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.token(tok)
+	}
+}
+
+type formatter struct {
+	opts  FormatOptions
+	buf   bytes.Buffer
+	names []string
+	// inExtension is the depth of the outermost Extension or
+	// CreativeExtension element, or 0.
+	inExtension int
+	// hasChildren tells whether the current element has child elements.
+	hasChildren bool
+	text        []byte
+}
+
+func (f *formatter) newline() {
+	if f.opts.Indent == "" || f.buf.Len() == 0 {
+		return
+	}
+	f.buf.WriteByte('\n')
+	for range f.names {
+		f.buf.WriteString(f.opts.Indent)
+	}
+}
+
+func (f *formatter) flushText() {
+	if len(f.text) == 0 {
+		return
+	}
+	name := ""
+	if len(f.names) > 0 {
+		name = f.names[len(f.names)-1]
+	}
+	if f.opts.Indent != "" && f.hasChildren {
+		// Whitespace between elements is replaced by the indentation.
+		if len(bytes.TrimSpace(f.text)) == 0 {
+			f.text = f.text[:0]
+			return
+		}
+	}
+	if f.opts.CDATA && f.inExtension == 0 && cdataElements[name] {
+		writeCDATA(&f.buf, f.text)
+	} else {
+		xml.EscapeText(&f.buf, f.text)
+	}
+	f.text = f.text[:0]
+}
+
+func (f *formatter) token(tok xml.Token) {
+	switch t := tok.(type) {
+	case xml.StartElement:
+		if len(f.names) > 0 {
+			f.hasChildren = true
+		}
+		f.flushText()
+		f.newline()
+		f.buf.WriteByte('<')
+		f.buf.WriteString(rawName(t.Name))
+		for _, attr := range t.Attr {
+			f.buf.WriteByte(' ')
+			f.buf.WriteString(rawName(attr.Name))
+			f.buf.WriteString(`="`)
+			xml.EscapeText(&f.buf, []byte(attr.Value))
+			f.buf.WriteByte('"')
+		}
+		f.buf.WriteByte('>')
+		f.names = append(f.names, t.Name.Local)
+		if (t.Name.Local == "Extension" || t.Name.Local == "CreativeExtension") && f.inExtension == 0 {
+			f.inExtension = len(f.names)
+		}
+		f.hasChildren = false
+	case xml.EndElement:
+		f.flushText()
+		f.names = f.names[:len(f.names)-1]
+		if f.hasChildren {
+			f.newline()
+		}
+		f.buf.WriteString("</")
+		f.buf.WriteString(rawName(t.Name))
+		f.buf.WriteByte('>')
+		if f.inExtension > len(f.names) {
+			f.inExtension = 0
+		}
+		f.hasChildren = true
+	case xml.CharData:
+		f.text = append(f.text, t...)
+	case xml.Comment:
+		f.flushText()
+		f.buf.WriteString("<!--")
+		f.buf.Write(t)
+		f.buf.WriteString("-->")
+	case xml.ProcInst:
+		f.flushText()
+		f.buf.WriteString("<?")
+		f.buf.WriteString(t.Target)
+		if len(t.Inst) > 0 {
+			f.buf.WriteByte(' ')
+			f.buf.Write(t.Inst)
+		}
+		f.buf.WriteString("?>")
+	case xml.Directive:
+		f.flushText()
+		f.buf.WriteString("<!")
+		f.buf.Write(t)
+		f.buf.WriteByte('>')
+	}
+}
+
+func rawName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// writeCDATA writes data as a CDATA section, splitting it where data
+// contains the "]]>" terminator.
+func writeCDATA(buf *bytes.Buffer, data []byte) {
+	buf.WriteString("<![CDATA[")
+	for {
+		i := bytes.Index(data, []byte("]]>"))
+		if i < 0 {
+			break
+		}
+		buf.Write(data[:i+2])
+		buf.WriteString("]]><![CDATA[")
+		data = data[i+2:]
+	}
+	buf.Write(data)
+	buf.WriteString("]]>")
+}
@@ -0,0 +1,125 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalize(t *testing.T) {
+	vast := &VAST{
+		Version: "2.0",
+		Ad: []Ad{{
+			InLine: &InLine{
+				Error:      []string{"\n  http://err.com  \n"},
+				Impression: []Impression{{Data: " http://imp.com "}},
+				Creatives: Creatives{Creative: []Creative{
+					{Linear: &Linear{
+						Duration:   " 0:00:30.500 ",
+						MediaFiles: MediaFiles{MediaFile: []MediaFile{{Data: "\thttp://media.com/a.mp4\n"}}},
+					}},
+					{Linear: &Linear{Duration: "30 seconds"}},
+				}},
+			},
+		}},
+	}
+	Canonicalize(vast)
+
+	inLine := vast.Ad[0].InLine
+	assert.Equal(t, inLine.Error, []string{"http://err.com"})
+	assert.Equal(t, inLine.Impression[0].Data, "http://imp.com")
+	assert.Equal(t, inLine.Creatives.Creative[0].Linear.Duration, "00:00:30.500")
+	assert.Equal(t, inLine.Creatives.Creative[0].Linear.MediaFiles.MediaFile[0].Data, "http://media.com/a.mp4")
+	assert.Equal(t, inLine.Creatives.Creative[1].Linear.Duration, "30 seconds")
+}
+
+func TestFormatMinified(t *testing.T) {
+	vast := testCloneVAST()
+	data, err := Format(vast, FormatOptions{})
+	assert.Nil(t, err)
+
+	res, err := xml.Marshal(vast)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), string(res))
+}
+
+func TestFormatIndent(t *testing.T) {
+	vast := testCloneVAST()
+	vast.Ad[0].InLine.Extensions = nil
+	vast.Ad[1].Wrapper.Extensions = nil
+	vast.Ad[0].InLine.Creatives.Creative[0].Linear.CreativeExtensions = nil
+
+	data, err := Format(vast, FormatOptions{Indent: "  "})
+	assert.Nil(t, err)
+
+	res, err := xml.MarshalIndent(vast, "", "  ")
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), string(res))
+}
+
+func TestFormatCDATA(t *testing.T) {
+	vast := &VAST{
+		Version: "2.0",
+		Ad: []Ad{{
+			ID: "1",
+			Wrapper: &Wrapper{
+				VASTAdTagURI: "http://tag.com/?a=1&b=2",
+				AdSystem:     AdSystem{Data: "a&b"},
+				Impression:   []Impression{{Data: "http://imp.com/]]>"}},
+				Extensions: &Extensions{Extension: []Extension{
+					{Type: "errors", Data: []byte("<Error>http://x.com/?a=1&amp;b=2</Error>")},
+				}},
+			},
+		}},
+	}
+
+	data, err := Format(vast, FormatOptions{CDATA: true})
+	assert.Nil(t, err)
+
+	res := `<VAST version="2.0"><Ad id="1"><Wrapper>` +
+		`<VASTAdTagURI><![CDATA[http://tag.com/?a=1&b=2]]></VASTAdTagURI>` +
+		`<AdSystem>a&amp;b</AdSystem>` +
+		`<Impression><![CDATA[http://imp.com/]]]]><![CDATA[>]]></Impression>` +
+		`<Creatives></Creatives>` +
+		`<Extensions><Extension type="errors"><Error>http://x.com/?a=1&amp;b=2</Error></Extension></Extensions>` +
+		`</Wrapper></Ad></VAST>`
+	assert.Equal(t, string(data), res)
+
+	var decoded VAST
+	assert.Nil(t, xml.Unmarshal(data, &decoded))
+	assert.Nil(t, Diff(vast, &decoded))
+}
+
+func TestFormatIndentExtensions(t *testing.T) {
+	vast := &VAST{
+		Version: "2.0",
+		Ad: []Ad{{
+			ID: "1",
+			Wrapper: &Wrapper{
+				Extensions: &Extensions{Extension: []Extension{
+					{Type: "pricing", Data: []byte("\n<Price currency=\"USD\">2.5</Price>\n")},
+				}},
+			},
+		}},
+	}
+
+	data, err := Format(vast, FormatOptions{Indent: "\t", CDATA: true})
+	assert.Nil(t, err)
+
+	res := "<VAST version=\"2.0\">\n" +
+		"\t<Ad id=\"1\">\n" +
+		"\t\t<Wrapper>\n" +
+		"\t\t\t<VASTAdTagURI></VASTAdTagURI>\n" +
+		"\t\t\t<AdSystem></AdSystem>\n" +
+		"\t\t\t<Creatives></Creatives>\n" +
+		"\t\t\t<Extensions>\n" +
+		"\t\t\t\t<Extension type=\"pricing\">\n" +
+		"\t\t\t\t\t<Price currency=\"USD\">2.5</Price>\n" +
+		"\t\t\t\t</Extension>\n" +
+		"\t\t\t</Extensions>\n" +
+		"\t\t</Wrapper>\n" +
+		"\t</Ad>\n" +
+		"</VAST>"
+	assert.Equal(t, string(data), res)
+}
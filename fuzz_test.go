@@ -0,0 +1,327 @@
+package vast2
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// maxFuzzInput bounds the size of the fuzzed documents.
+const maxFuzzInput = 64 << 10
+
+// maxDecodeAlloc bounds the bytes allocated to decode a document of n bytes.
+func maxDecodeAlloc(n int) uint64 {
+	return 64<<10 + 256*uint64(n)
+}
+
+// decodeAlloc decodes data into v and returns the bytes allocated meanwhile.
+func decodeAlloc(data []byte, v *VAST) (uint64, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := xml.Unmarshal(data, v)
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc, err
+}
+
+const textAlphabet = "abcXYZ019 :/?=&.-_<>\"'\n\té€"
+
+func randomText(r *rand.Rand) string {
+	n := r.Intn(12)
+	runes := []rune(textAlphabet)
+	s := make([]rune, n)
+	for i := range s {
+		s[i] = runes[r.Intn(len(runes))]
+	}
+	return string(s)
+}
+
+func randomURL(r *rand.Rand) string {
+	if r.Intn(5) == 0 {
+		return ""
+	}
+	return "http://example.com/" + randomText(r)
+}
+
+func randomURLs(r *rand.Rand) []string {
+	var urls []string
+	for i := r.Intn(3); i > 0; i-- {
+		urls = append(urls, randomURL(r))
+	}
+	return urls
+}
+
+func randomCreativeExtensions(r *rand.Rand) *CreativeExtensions {
+	if r.Intn(2) == 0 {
+		return nil
+	}
+	exts := &CreativeExtensions{}
+	for i := r.Intn(3); i > 0; i-- {
+		var data bytes.Buffer
+		xml.EscapeText(&data, []byte(randomText(r)))
+		exts.CreativeExtension = append(exts.CreativeExtension, CreativeExtension{
+			Type: randomText(r),
+			Data: []byte("<Value>" + data.String() + "</Value>"),
+		})
+	}
+	return exts
+}
+
+func randomTrackingEvents(r *rand.Rand) *TrackingEvents {
+	if r.Intn(3) == 0 {
+		return nil
+	}
+	events := &TrackingEvents{}
+	for i := r.Intn(4); i > 0; i-- {
+		events.Tracking = append(events.Tracking, Tracking{Event: randomText(r), Data: randomURL(r)})
+	}
+	return events
+}
+
+func randomStaticResource(r *rand.Rand) *StaticResource {
+	if r.Intn(2) == 0 {
+		return nil
+	}
+	return &StaticResource{CreativeType: randomText(r), Data: randomURL(r)}
+}
+
+func randomLinear(r *rand.Rand) *Linear {
+	linear := &Linear{
+		Duration:           randomText(r),
+		AdParameters:       randomText(r),
+		TrackingEvents:     randomTrackingEvents(r),
+		CreativeExtensions: randomCreativeExtensions(r),
+	}
+	if r.Intn(2) == 0 {
+		linear.VideoClicks = &VideoClicks{ClickThrough: randomURL(r)}
+		for i := r.Intn(3); i > 0; i-- {
+			linear.VideoClicks.ClickTracking = append(linear.VideoClicks.ClickTracking, ClickTracking{ID: randomText(r), Data: randomURL(r)})
+		}
+		for i := r.Intn(3); i > 0; i-- {
+			linear.VideoClicks.CustomClick = append(linear.VideoClicks.CustomClick, CustomClick{ID: randomText(r), Data: randomURL(r)})
+		}
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		linear.MediaFiles.MediaFile = append(linear.MediaFiles.MediaFile, MediaFile{
+			ID:                  randomText(r),
+			Delivery:            randomText(r),
+			Type:                randomText(r),
+			Bitrate:             r.Intn(5000),
+			Width:               r.Intn(2000) - 10,
+			Height:              r.Intn(2000),
+			Scalable:            r.Intn(2) == 0,
+			MaintainAspectRatio: r.Intn(2) == 0,
+			ApiFramework:        randomText(r),
+			Data:                randomURL(r),
+		})
+	}
+	return linear
+}
+
+func randomCompanionAds(r *rand.Rand) *CompanionAds {
+	companionAds := &CompanionAds{}
+	for i := r.Intn(3); i > 0; i-- {
+		companionAds.Companion = append(companionAds.Companion, Companion{
+			ID:                    randomText(r),
+			Width:                 r.Intn(1000),
+			Height:                r.Intn(1000),
+			ExpandedWidth:         r.Intn(1000),
+			ExpandedHeight:        r.Intn(1000),
+			ApiFramework:          randomText(r),
+			IFrameResource:        randomURL(r),
+			HTMLResource:          randomText(r),
+			CompanionClickThrough: randomURL(r),
+			AltText:               randomText(r),
+			AdParameters:          randomText(r),
+			StaticResource:        randomStaticResource(r),
+			TrackingEvents:        randomTrackingEvents(r),
+			CreativeExtensions:    randomCreativeExtensions(r),
+		})
+	}
+	return companionAds
+}
+
+func randomNonLinearAds(r *rand.Rand) *NonLinearAds {
+	nonLinearAds := &NonLinearAds{TrackingEvents: randomTrackingEvents(r)}
+	for i := r.Intn(3); i > 0; i-- {
+		nonLinearAds.NonLinear = append(nonLinearAds.NonLinear, NonLinear{
+			ID:                    randomText(r),
+			Width:                 r.Intn(1000),
+			Height:                r.Intn(1000),
+			ExpandedWidth:         r.Intn(1000),
+			ExpandedHeight:        r.Intn(1000),
+			Scalable:              r.Intn(2) == 0,
+			MaintainAspectRatio:   r.Intn(2) == 0,
+			ApiFramework:          randomText(r),
+			MinSuggestedDuration:  randomText(r),
+			IFrameResource:        randomURL(r),
+			HTMLResource:          randomText(r),
+			AdParameters:          randomText(r),
+			NonLinearClickThrough: randomURL(r),
+			StaticResource:        randomStaticResource(r),
+			CreativeExtensions:    randomCreativeExtensions(r),
+		})
+	}
+	return nonLinearAds
+}
+
+func randomCreatives(r *rand.Rand) Creatives {
+	var creatives Creatives
+	for i := r.Intn(4); i > 0; i-- {
+		creative := Creative{ID: randomText(r), Sequence: r.Intn(4), AdID: randomText(r)}
+		switch r.Intn(4) {
+		case 0:
+			creative.Linear = randomLinear(r)
+		case 1:
+			creative.CompanionAds = randomCompanionAds(r)
+		case 2:
+			creative.NonLinearAds = randomNonLinearAds(r)
+		}
+		creatives.Creative = append(creatives.Creative, creative)
+	}
+	return creatives
+}
+
+func randomImpressions(r *rand.Rand) []Impression {
+	var imps []Impression
+	for i := r.Intn(3); i > 0; i-- {
+		imps = append(imps, Impression{ID: randomText(r), Data: randomURL(r)})
+	}
+	return imps
+}
+
+func randomExtensions(r *rand.Rand) *Extensions {
+	if r.Intn(2) == 0 {
+		return nil
+	}
+	exts := &Extensions{}
+	for i := r.Intn(3); i > 0; i-- {
+		var data bytes.Buffer
+		xml.EscapeText(&data, []byte(randomText(r)))
+		exts.Extension = append(exts.Extension, Extension{
+			Type: randomText(r),
+			Data: []byte("<Value>" + data.String() + "</Value>"),
+		})
+	}
+	return exts
+}
+
+// randomVAST returns a random document which can be encoded.
+func randomVAST(r *rand.Rand) *VAST {
+	v := &VAST{Version: randomText(r)}
+	for i := r.Intn(4); i > 0; i-- {
+		ad := Ad{ID: randomText(r)}
+		if r.Intn(2) == 0 {
+			ad.InLine = &InLine{
+				AdTitle:     randomText(r),
+				Description: randomText(r),
+				Survey:      randomURL(r),
+				Error:       randomURLs(r),
+				AdSystem:    AdSystem{Version: randomText(r), Data: randomText(r)},
+				Impression:  randomImpressions(r),
+				Creatives:   randomCreatives(r),
+				Extensions:  randomExtensions(r),
+			}
+		} else {
+			ad.Wrapper = &Wrapper{
+				VASTAdTagURI: randomURL(r),
+				Error:        randomURLs(r),
+				AdSystem:     AdSystem{Version: randomText(r), Data: randomText(r)},
+				Impression:   randomImpressions(r),
+				Creatives:    randomCreatives(r),
+				Extensions:   randomExtensions(r),
+			}
+		}
+		v.Ad = append(v.Ad, ad)
+	}
+	return v
+}
+
+// checkRoundTrip checks that encoding the decoded encoding of v gives the
+// same bytes.
+func checkRoundTrip(t *testing.T, v *VAST) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var decoded VAST
+	if err := xml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	again, err := xml.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("encode decoded: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Fatalf("round trip is not stable:\n%s\n%s", data, again)
+	}
+}
+
+func TestRoundTripProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		checkRoundTrip(t, randomVAST(r))
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		checkRoundTrip(t, randomVAST(rand.New(rand.NewSource(seed))))
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, seed := range []*VAST{testCloneVAST(), testValidVAST(), {}} {
+		data, err := xml.Marshal(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`<VAST version="2.0"><Ad><InLine><Creatives><Creative><Linear>`))
+	f.Add([]byte(`<VAST><Ad id="1"><Wrapper><![CDATA[x]]></Wrapper></Ad></VAST>`))
+	f.Add([]byte(`<?xml version="1.0"?><!DOCTYPE VAST><VAST xmlns:x="y"><x:Ad/></VAST>`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > maxFuzzInput {
+			return
+		}
+		var v VAST
+		alloc, err := decodeAlloc(data, &v)
+		if alloc > maxDecodeAlloc(len(data)) {
+			t.Fatalf("decoding %d bytes allocated %d bytes", len(data), alloc)
+		}
+		if err != nil {
+			return
+		}
+
+		// Everything built on the model must cope with any decoded document.
+		Validate(&v)
+		Diff(&v, v.Clone())
+		Canonicalize(v.Clone())
+		_, err = Format(&v, FormatOptions{Indent: "  ", CDATA: true})
+		if err != nil && err != ErrAdChoice && err != ErrCreativeChoice {
+			t.Fatalf("format: %v", err)
+		}
+	})
+}
+
+func TestDecodeAlloc(t *testing.T) {
+	for _, data := range []string{
+		strings.Repeat("<Ad>", maxFuzzInput/4),
+		"<VAST>" + strings.Repeat(`<Ad id="1"><InLine><Impression>http://imp.com</Impression></InLine></Ad>`, 800) + "</VAST>",
+		"<VAST" + strings.Repeat(` a="b"`, 10000) + "/>",
+	} {
+		var v VAST
+		alloc, _ := decodeAlloc([]byte(data), &v)
+		if alloc > maxDecodeAlloc(len(data)) {
+			t.Errorf("decoding %d bytes allocated %d bytes", len(data), alloc)
+		}
+		t.Logf("%d bytes: %d allocated", len(data), alloc)
+	}
+}
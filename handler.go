@@ -0,0 +1,127 @@
+package vast2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler serves the VAST document returned for each request. A no-fill
+// response is served if it returns nil.
+type Handler func(r *http.Request) *VAST
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		// CORS preflight request.
+		setCORSHeaders(w, r)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	v := h(r)
+	if v == nil {
+		ServeNoFill(w, r)
+		return
+	}
+	ServeVAST(w, r, v)
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// ServeVAST replies to r with v, preceded by the XML declaration. The
+// response is gzipped if r accepts it, has an ETag computed from its content
+// and the CORS headers HTML5 video players need to read it with
+// credentials. Conditional and range requests are handled as by
+// http.ServeContent. A 500 status is sent if v holds an Ad or Creative with
+// an ambiguous Kind.
+func ServeVAST(w http.ResponseWriter, r *http.Request, v *VAST) {
+	bp := encodeBufPool.Get().(*[]byte)
+	defer encodeBufPool.Put(bp)
+	e := encoder{b: append((*bp)[:0], xml.Header...)}
+	e.vast(v)
+	*bp = e.b
+	if e.err != nil {
+		http.Error(w, e.err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	body := e.b
+	h := fnv.New64a()
+	h.Write(body)
+	etag := strconv.FormatUint(h.Sum64(), 16)
+
+	header := w.Header()
+	setCORSHeaders(w, r)
+	header.Add("Vary", "Accept-Encoding")
+	header.Set("Content-Type", "application/xml")
+	if acceptsGzip(r) {
+		var buf bytes.Buffer
+		zw := gzipWriterPool.Get().(*gzip.Writer)
+		zw.Reset(&buf)
+		zw.Write(body)
+		zw.Close()
+		gzipWriterPool.Put(zw)
+		body = buf.Bytes()
+		etag += "-gzip"
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// ServeNoFill replies to r with a VAST 2.0 document without Ads, which is
+// how VAST 2.0 signals that no ad is available.
+func ServeNoFill(w http.ResponseWriter, r *http.Request) {
+	ServeVAST(w, r, NoFill())
+}
+
+// setCORSHeaders allows the origin of r, if any, to read the response with
+// credentials, as players send the cookies of the ad server.
+func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	if origin := r.Header.Get("Origin"); origin != "" {
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// acceptsGzip tells whether the Accept-Encoding of r lists gzip with a
+// non-zero quality.
+func acceptsGzip(r *http.Request) bool {
+	for _, h := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(h, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+			params = strings.ReplaceAll(params, " ", "")
+			if strings.HasPrefix(params, "q=") {
+				if q, err := strconv.ParseFloat(params[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
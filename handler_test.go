@@ -0,0 +1,120 @@
+package vast2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeVAST(t *testing.T) {
+	v := &VAST{Version: "2.0", Ad: []Ad{testInLineAd()}}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + string(mustAppendXML(t, v, nil))
+
+	r := httptest.NewRequest(http.MethodGet, "/vast", nil)
+	r.Header.Set("Origin", "https://player.com")
+	w := httptest.NewRecorder()
+	ServeVAST(w, r, v)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), want)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/xml")
+	assert.Equal(t, w.Header().Get("Access-Control-Allow-Origin"), "https://player.com")
+	assert.Equal(t, w.Header().Get("Access-Control-Allow-Credentials"), "true")
+	assert.Equal(t, w.Header().Values("Vary"), []string{"Origin", "Accept-Encoding"})
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "")
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+"$`, etag)
+
+	r = httptest.NewRequest(http.MethodGet, "/vast", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	ServeVAST(w, r, v)
+	assert.Equal(t, w.Code, http.StatusNotModified)
+	assert.Equal(t, w.Header().Get("Access-Control-Allow-Origin"), "")
+}
+
+func TestServeVASTGzip(t *testing.T) {
+	v := &VAST{Version: "2.0", Ad: []Ad{testInLineAd()}}
+	r := httptest.NewRequest(http.MethodGet, "/vast", nil)
+	r.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	w := httptest.NewRecorder()
+	ServeVAST(w, r, v)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "gzip")
+	assert.Regexp(t, `^"[0-9a-f]+-gzip"$`, w.Header().Get("ETag"))
+	etag, body := w.Header().Get("ETag"), w.Body.Bytes()
+	zr, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasSuffix(data, mustAppendXML(t, v, nil)))
+
+	// The pooled gzip.Writer is reset for each response.
+	w = httptest.NewRecorder()
+	ServeVAST(w, r, New(testWrapperAd("w", "https://ads.example.com")))
+	w = httptest.NewRecorder()
+	ServeVAST(w, r, v)
+	assert.Equal(t, w.Header().Get("ETag"), etag)
+	assert.Equal(t, w.Body.Bytes(), body)
+
+	for _, accept := range []string{"gzip;q=0", "br", "identity"} {
+		r.Header.Set("Accept-Encoding", accept)
+		w = httptest.NewRecorder()
+		ServeVAST(w, r, v)
+		assert.Equal(t, w.Header().Get("Content-Encoding"), "", accept)
+	}
+}
+
+func TestServeVASTAmbiguous(t *testing.T) {
+	v := &VAST{Version: "2.0", Ad: []Ad{{InLine: &InLine{}, Wrapper: &Wrapper{}}}}
+	w := httptest.NewRecorder()
+	ServeVAST(w, httptest.NewRequest(http.MethodGet, "/vast", nil), v)
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Body.String(), ErrAdChoice.Error()+"\n")
+}
+
+func TestServeNoFill(t *testing.T) {
+	w := httptest.NewRecorder()
+	ServeNoFill(w, httptest.NewRequest(http.MethodGet, "/vast", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<VAST version="2.0"></VAST>`)
+}
+
+func TestHandler(t *testing.T) {
+	v := &VAST{Version: "2.0", Ad: []Ad{testInLineAd()}}
+	h := Handler(func(r *http.Request) *VAST {
+		if r.URL.Query().Get("fill") == "" {
+			return nil
+		}
+		return v
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/vast?fill=1", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.True(t, bytes.HasSuffix(w.Body.Bytes(), mustAppendXML(t, v, nil)))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/vast", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.Len(), 0)
+
+	r := httptest.NewRequest(http.MethodOptions, "/vast", nil)
+	r.Header.Set("Origin", "https://player.com")
+	r.Header.Set("Access-Control-Request-Headers", "X-Player")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.Equal(t, w.Header().Get("Access-Control-Allow-Origin"), "https://player.com")
+	assert.Equal(t, w.Header().Get("Access-Control-Allow-Methods"), "GET, HEAD, OPTIONS")
+	assert.Equal(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Player")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/vast", nil))
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+	assert.Equal(t, w.Header().Get("Allow"), "GET, HEAD, OPTIONS")
+}
@@ -0,0 +1,59 @@
+package vast2
+
+import "encoding/xml"
+
+// DefaultVersion is the version written for documents without one.
+const DefaultVersion = "2.0"
+
+// New returns a VAST 2.0 document holding ads.
+func New(ads ...Ad) *VAST {
+	return &VAST{Version: DefaultVersion, Ad: ads}
+}
+
+// NoFill returns the VAST 2.0 document without Ads which tells players that
+// no ad is available.
+func NoFill() *VAST {
+	return New()
+}
+
+// IsNoFill reports whether v, which may be nil, has no Ad.
+func (v *VAST) IsNoFill() bool {
+	return v == nil || len(v.Ad) == 0
+}
+
+// IsEmpty reports whether v has nothing to play: it is a no-fill or every Ad
+// is either empty or an InLine Ad without Creatives, such as the error-only
+// responses which only carry Error URLs. Wrapper Ads are not empty as their
+// content is not known before they are resolved.
+func (v *VAST) IsEmpty() bool {
+	if v.IsNoFill() {
+		return true
+	}
+	for i := range v.Ad {
+		switch v.Ad[i].Kind() {
+		case AdEmpty:
+		case AdInLine:
+			if len(v.Ad[i].InLine.Creatives.Creative) > 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// version returns the version to encode v with.
+func (v *VAST) version() string {
+	if v.Version == "" {
+		return DefaultVersion
+	}
+	return v.Version
+}
+
+// MarshalXML encodes v, with DefaultVersion if it has no Version.
+func (v VAST) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type vast VAST
+	v.Version = v.version()
+	return e.EncodeElement(vast(v), start)
+}
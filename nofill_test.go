@@ -0,0 +1,65 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoFill(t *testing.T) {
+	v := NoFill()
+	data, err := xml.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<VAST version="2.0"></VAST>`)
+	assert.Equal(t, string(mustAppendXML(t, v, nil)), `<VAST version="2.0"></VAST>`)
+	assert.True(t, v.IsNoFill())
+	assert.True(t, v.IsEmpty())
+}
+
+func TestNew(t *testing.T) {
+	v := New(testInLineAd())
+	assert.Equal(t, v.Version, "2.0")
+	assert.Len(t, v.Ad, 1)
+	assert.False(t, v.IsNoFill())
+	assert.False(t, v.IsEmpty())
+}
+
+func TestVASTDefaultVersion(t *testing.T) {
+	v := &VAST{Ad: []Ad{{ID: "1"}}}
+	data, err := xml.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<VAST version="2.0"><Ad id="1"></Ad></VAST>`)
+	assert.Equal(t, string(mustAppendXML(t, v, nil)), string(data))
+	assert.Equal(t, v.Version, "")
+
+	v.Version = "2.0.1"
+	assert.Equal(t, string(mustAppendXML(t, v, nil)), `<VAST version="2.0.1"><Ad id="1"></Ad></VAST>`)
+}
+
+func TestIsNoFill(t *testing.T) {
+	var v VAST
+	assert.Nil(t, xml.Unmarshal([]byte(`<VAST version="2.0"/>`), &v))
+	assert.True(t, v.IsNoFill())
+	assert.True(t, (*VAST)(nil).IsNoFill())
+	assert.True(t, (*VAST)(nil).IsEmpty())
+
+	assert.Nil(t, xml.Unmarshal([]byte(`<VAST version="2.0"><Ad id="1"/></VAST>`), &v))
+	assert.False(t, v.IsNoFill())
+	assert.True(t, v.IsEmpty())
+}
+
+func TestIsEmpty(t *testing.T) {
+	errorOnly := `<VAST version="2.0"><Ad id="1"><InLine><AdSystem>x</AdSystem><AdTitle>x</AdTitle>` +
+		`<Error>http://error.com</Error><Creatives></Creatives></InLine></Ad></VAST>`
+	var v VAST
+	assert.Nil(t, xml.Unmarshal([]byte(errorOnly), &v))
+	assert.False(t, v.IsNoFill())
+	assert.True(t, v.IsEmpty())
+
+	v.Ad = append(v.Ad, NewWrapperAd("2", &Wrapper{VASTAdTagURI: "http://tag.com"}))
+	assert.False(t, v.IsEmpty())
+
+	assert.False(t, New(Ad{}, testInLineAd()).IsEmpty())
+	assert.False(t, New(Ad{InLine: &InLine{}, Wrapper: &Wrapper{}}).IsEmpty())
+}
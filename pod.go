@@ -0,0 +1,205 @@
+package vast2
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type PodOptions struct {
+	// MaxDuration is the length of the break, which the summed durations
+	// of the Linear creatives of the pod never exceed.
+	MaxDuration time.Duration
+	// MaxAds bounds the number of Ads in the pod. 0 means no limit.
+	MaxAds int
+}
+
+// maxPodCandidates bounds the candidates among which AssemblePod searches
+// the best fit, the later ones being added first-fit.
+const maxPodCandidates = 16
+
+// AssemblePod returns a VAST 2.0 document holding the ads to play in a break
+// described by opts, in playback order.
+//
+// ads are candidates in order of priority. The pod is the set of candidates
+// filling the most of the break, preferring the earlier candidates among the
+// sets filling it as much, and is played in order of priority. Two ads are
+// not both selected if they have Linear creatives with the same AdSystem and
+// AdID, or the same AdSystem and Ad ID for creatives without an AdID. The
+// search is exhaustive among the first 16 candidates which fit in the break,
+// the following ones being added if they fit in the remaining duration. Only
+// InLine Ads whose Linear creatives all have a valid Duration are
+// considered: resolve Wrappers with a Resolver first. The creatives of the
+// selected ads are ordered by Sequence, those without one keeping their
+// place after the sequenced ones. ads are not modified.
+func AssemblePod(ads []Ad, opts PodOptions) *VAST {
+	var candidates []podCandidate
+	for i := range ads {
+		d, ok := podDuration(&ads[i])
+		if !ok || d > opts.MaxDuration {
+			continue
+		}
+		candidates = append(candidates, podCandidate{index: i, duration: d, keys: podKeys(&ads[i])})
+	}
+	searched := candidates
+	if len(searched) > maxPodCandidates {
+		searched = searched[:maxPodCandidates]
+	}
+
+	s := podSearch{candidates: searched, opts: opts, seen: map[podKey]int{}}
+	s.search(0, 0)
+	selected := s.best
+	seen := map[podKey]int{}
+	remaining := opts.MaxDuration - s.bestDuration
+	for _, i := range selected {
+		for _, k := range candidates[i].keys {
+			seen[k]++
+		}
+	}
+	for i := len(searched); i < len(candidates); i++ {
+		c := candidates[i]
+		if opts.MaxAds > 0 && len(selected) >= opts.MaxAds {
+			break
+		}
+		if c.duration > remaining || hasPodKey(seen, c.keys) {
+			continue
+		}
+		for _, k := range c.keys {
+			seen[k]++
+		}
+		remaining -= c.duration
+		selected = append(selected, i)
+	}
+
+	pod := New()
+	for _, i := range selected {
+		ad := ads[candidates[i].index].Clone()
+		sortCreatives(ad.InLine.Creatives.Creative)
+		pod.Ad = append(pod.Ad, ad)
+	}
+	return pod
+}
+
+type podCandidate struct {
+	// index is the position of the Ad in the candidates of AssemblePod.
+	index    int
+	duration time.Duration
+	keys     []podKey
+}
+
+// podSearch finds the subset of candidates with the longest duration within
+// the break, preferring the earlier candidates on ties.
+type podSearch struct {
+	candidates []podCandidate
+	opts       PodOptions
+
+	// selected are the indexes of the candidates of the current subset and
+	// seen counts their keys.
+	selected []int
+	seen     map[podKey]int
+
+	best         []int
+	bestDuration time.Duration
+}
+
+// search extends the current subset, of duration d, with the candidates from
+// i on. It reports whether the break is filled.
+func (s *podSearch) search(i int, d time.Duration) bool {
+	if d > s.bestDuration || s.best == nil {
+		s.best = append([]int(nil), s.selected...)
+		s.bestDuration = d
+		if d == s.opts.MaxDuration {
+			return true
+		}
+	}
+	if i == len(s.candidates) || (s.opts.MaxAds > 0 && len(s.selected) >= s.opts.MaxAds) {
+		return false
+	}
+	// Including the candidate is tried first for earlier candidates to win
+	// ties.
+	c := s.candidates[i]
+	if d+c.duration <= s.opts.MaxDuration && !hasPodKey(s.seen, c.keys) {
+		s.selected = append(s.selected, i)
+		for _, k := range c.keys {
+			s.seen[k]++
+		}
+		filled := s.search(i+1, d+c.duration)
+		for _, k := range c.keys {
+			s.seen[k]--
+		}
+		s.selected = s.selected[:len(s.selected)-1]
+		if filled {
+			return true
+		}
+	}
+	return s.search(i+1, d)
+}
+
+// podDuration returns the summed durations of the Linear creatives of ad. It
+// fails if ad is not an InLine Ad with Linear creatives of valid durations.
+func podDuration(ad *Ad) (time.Duration, bool) {
+	if ad.Kind() != AdInLine {
+		return 0, false
+	}
+	var total time.Duration
+	n := 0
+	for _, c := range ad.InLine.Creatives.Creative {
+		if c.Linear == nil {
+			continue
+		}
+		d, err := ParseDuration(c.Linear.Duration)
+		if err != nil || d <= 0 {
+			return 0, false
+		}
+		total += d
+		n++
+	}
+	return total, n > 0
+}
+
+// podKey identifies the creatives which must not be repeated in a pod, by
+// their AdID or, if they have none, the ID of their Ad.
+type podKey struct {
+	adSystem string
+	adID     string
+	id       string
+}
+
+func podKeys(ad *Ad) []podKey {
+	var keys []podKey
+	adSystem := strings.TrimSpace(ad.InLine.AdSystem.Data)
+	for _, c := range ad.InLine.Creatives.Creative {
+		if c.Linear == nil {
+			continue
+		}
+		k := podKey{adSystem: adSystem, adID: strings.TrimSpace(c.AdID)}
+		if k.adID == "" {
+			if k.id = strings.TrimSpace(ad.ID); k.id == "" {
+				continue
+			}
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func hasPodKey(seen map[podKey]int, keys []podKey) bool {
+	for _, k := range keys {
+		if seen[k] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sortCreatives orders creatives by Sequence, putting those without one
+// last.
+func sortCreatives(creatives []Creative) {
+	sort.SliceStable(creatives, func(i, j int) bool {
+		si, sj := creatives[i].Sequence, creatives[j].Sequence
+		if si <= 0 || sj <= 0 {
+			return si > 0 && sj <= 0
+		}
+		return si < sj
+	})
+}
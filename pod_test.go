@@ -0,0 +1,114 @@
+package vast2
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPodAd(id, adSystem string, creatives ...Creative) Ad {
+	return NewInLineAd(id, &InLine{
+		AdTitle:    id,
+		AdSystem:   AdSystem{Data: adSystem},
+		Impression: []Impression{{Data: "http://imp.com/" + id}},
+		Creatives:  Creatives{Creative: creatives},
+	})
+}
+
+func testPodCreative(adID string, sequence int, duration string) Creative {
+	c := NewLinearCreative(&Linear{Duration: duration})
+	c.AdID = adID
+	c.Sequence = sequence
+	return c
+}
+
+func podAdIDs(v *VAST) []string {
+	var ids []string
+	for _, ad := range v.Ad {
+		ids = append(ids, ad.ID)
+	}
+	return ids
+}
+
+func TestAssemblePod(t *testing.T) {
+	ads := []Ad{
+		testPodAd("a", "x", testPodCreative("1", 0, "00:00:30")),
+		testPodAd("long", "x", testPodCreative("2", 0, "00:01:00")),
+		testPodAd("duplicate", " x ", testPodCreative("1", 0, "00:00:15")),
+		testPodAd("other-system", "y", testPodCreative("1", 0, "00:00:15")),
+		NewWrapperAd("wrapper", &Wrapper{VASTAdTagURI: "http://tag.com"}),
+		testPodAd("invalid", "x", testPodCreative("3", 0, "15s")),
+		testPodAd("no-linear", "x", NewCompanionAdsCreative(&CompanionAds{})),
+		testPodAd("b", "x", testPodCreative("", 0, "00:00:10"), testPodCreative("", 0, "00:00:05")),
+		testPodAd("c", "x", testPodCreative("4", 0, "00:00:05")),
+	}
+	pod := AssemblePod(ads, PodOptions{MaxDuration: 60 * time.Second})
+	assert.Equal(t, pod.Version, "2.0")
+	assert.Equal(t, podAdIDs(pod), []string{"a", "other-system", "b"})
+	assert.Equal(t, ads[0], testPodAd("a", "x", testPodCreative("1", 0, "00:00:30")))
+
+	pod = AssemblePod(ads, PodOptions{MaxDuration: 60 * time.Second, MaxAds: 1})
+	assert.Equal(t, podAdIDs(pod), []string{"long"})
+
+	pod = AssemblePod(ads, PodOptions{MaxDuration: 20 * time.Second})
+	assert.Equal(t, podAdIDs(pod), []string{"duplicate", "c"})
+
+	pod = AssemblePod(ads, PodOptions{})
+	assert.Nil(t, pod.Ad)
+}
+
+func TestAssemblePodBestFit(t *testing.T) {
+	ads := []Ad{
+		testPodAd("a", "x", testPodCreative("1", 0, "00:00:20")),
+		testPodAd("b", "x", testPodCreative("2", 0, "00:00:15")),
+		testPodAd("c", "x", testPodCreative("3", 0, "00:00:15")),
+		testPodAd("d", "x", testPodCreative("4", 0, "00:00:10")),
+	}
+	pod := AssemblePod(ads, PodOptions{MaxDuration: 30 * time.Second})
+	assert.Equal(t, podAdIDs(pod), []string{"a", "d"})
+	pod = AssemblePod(ads[:3], PodOptions{MaxDuration: 30 * time.Second})
+	assert.Equal(t, podAdIDs(pod), []string{"b", "c"})
+	pod = AssemblePod(ads[:3], PodOptions{MaxDuration: 30 * time.Second, MaxAds: 1})
+	assert.Equal(t, podAdIDs(pod), []string{"a"})
+
+	// The candidates after the first 16 are added first-fit.
+	var many []Ad
+	for i := 0; i < maxPodCandidates; i++ {
+		many = append(many, testPodAd(strconv.Itoa(i), "x", testPodCreative(strconv.Itoa(i), 0, "00:00:20")))
+	}
+	many = append(many, testPodAd("late", "x", testPodCreative("late", 0, "00:00:10")))
+	pod = AssemblePod(many, PodOptions{MaxDuration: 30 * time.Second})
+	assert.Equal(t, podAdIDs(pod), []string{"0", "late"})
+}
+
+func TestAssemblePodAdIDFallback(t *testing.T) {
+	ads := []Ad{
+		testPodAd("a", "x", testPodCreative("", 0, "00:00:10")),
+		testPodAd("a", " x ", testPodCreative("", 0, "00:00:10")),
+		testPodAd("a", "y", testPodCreative("", 0, "00:00:10")),
+		testPodAd("", "x", testPodCreative("", 0, "00:00:10")),
+		testPodAd("", "x", testPodCreative("", 0, "00:00:10")),
+	}
+	pod := AssemblePod(ads, PodOptions{MaxDuration: time.Minute})
+	assert.Equal(t, len(pod.Ad), 4)
+	assert.Equal(t, pod.Ad[1].InLine.AdSystem.Data, "y")
+}
+
+func TestAssemblePodSequence(t *testing.T) {
+	companion := NewCompanionAdsCreative(&CompanionAds{})
+	ad := testPodAd("a", "x",
+		testPodCreative("none", 0, "00:00:05"),
+		testPodCreative("third", 3, "00:00:05"),
+		companion,
+		testPodCreative("first", 1, "00:00:05"),
+	)
+	pod := AssemblePod([]Ad{ad}, PodOptions{MaxDuration: 15 * time.Second})
+	var order []string
+	for _, c := range pod.Ad[0].InLine.Creatives.Creative {
+		order = append(order, c.AdID)
+	}
+	assert.Equal(t, order, []string{"first", "third", "none", ""})
+	assert.Equal(t, ad.InLine.Creatives.Creative[0].AdID, "none")
+}
@@ -0,0 +1,93 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RangeReader reads a remote asset with HTTP range requests, so that only
+// the headers needed by Probe are downloaded.
+type RangeReader struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+	size   int64
+}
+
+// NewRangeReader returns a RangeReader for the asset at url, whose size is
+// read from the response to a request for its first byte. The server must
+// support range requests. http.DefaultClient is used if client is nil.
+func NewRangeReader(ctx context.Context, client *http.Client, url string) (*RangeReader, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &RangeReader{ctx: ctx, client: client, url: url}
+	resp, err := r.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	// Content-Range is "bytes 0-0/<size>".
+	cr := resp.Header.Get("Content-Range")
+	i := strings.LastIndexByte(cr, '/')
+	if i < 0 {
+		return nil, fmt.Errorf("probe: %s: invalid Content-Range %q", url, cr)
+	}
+	if r.size, err = strconv.ParseInt(cr[i+1:], 10, 64); err != nil {
+		return nil, fmt.Errorf("probe: %s: invalid Content-Range %q", url, cr)
+	}
+	return r, nil
+}
+
+// Size returns the size of the asset in bytes.
+func (r *RangeReader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt with a range request per call.
+func (r *RangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	last := off + int64(len(p)) - 1
+	if last >= r.size {
+		last = r.size - 1
+	}
+	resp, err := r.get(off, last)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	n, err := io.ReadFull(resp.Body, p[:last-off+1])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// get requests the bytes between first and last included.
+func (r *RangeReader) get(first, last int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", first, last))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("probe: %s: unexpected status %d for a range request", r.url, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,156 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxMP4HeaderBox bounds the size of the boxes read in memory.
+const maxMP4HeaderBox = 1 << 20
+
+type mp4Box struct {
+	typ string
+	// off and end delimit the content of the box.
+	off, end int64
+}
+
+// mp4Boxes returns the boxes between off and end.
+func mp4Boxes(r io.ReaderAt, off, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for off+8 <= end {
+		h, err := readAt(r, off, 8)
+		if err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(h))
+		header := int64(8)
+		switch size {
+		case 0:
+			size = end - off
+		case 1:
+			large, err := readAt(r, off+8, 8)
+			if err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(large))
+			header = 16
+		}
+		if size < header || size > end-off {
+			return nil, fmt.Errorf("probe: invalid MP4 box %q at %d", h[4:8], off)
+		}
+		boxes = append(boxes, mp4Box{typ: string(h[4:8]), off: off + header, end: off + size})
+		off += size
+	}
+	return boxes, nil
+}
+
+func findMP4Box(boxes []mp4Box, typ string) (mp4Box, bool) {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+func readMP4Box(r io.ReaderAt, b mp4Box) ([]byte, error) {
+	if b.end-b.off > maxMP4HeaderBox {
+		return nil, fmt.Errorf("probe: MP4 %s box too large", b.typ)
+	}
+	return readAt(r, b.off, int(b.end-b.off))
+}
+
+// probeMP4 reads the duration from moov/mvhd and the dimensions from the
+// tkhd of the first track with some.
+func probeMP4(r io.ReaderAt, size int64) (Info, error) {
+	boxes, err := mp4Boxes(r, 0, size)
+	if err != nil {
+		return Info{}, err
+	}
+	moov, ok := findMP4Box(boxes, "moov")
+	if !ok {
+		return Info{}, errors.New("probe: MP4 has no moov box")
+	}
+	children, err := mp4Boxes(r, moov.off, moov.end)
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{MIMEType: "video/mp4"}
+	mvhd, ok := findMP4Box(children, "mvhd")
+	if !ok {
+		return Info{}, errors.New("probe: MP4 has no mvhd box")
+	}
+	data, err := readMP4Box(r, mvhd)
+	if err != nil {
+		return Info{}, err
+	}
+	if info.Duration, err = parseMVHD(data); err != nil {
+		return Info{}, err
+	}
+
+	for _, trak := range children {
+		if trak.typ != "trak" {
+			continue
+		}
+		boxes, err := mp4Boxes(r, trak.off, trak.end)
+		if err != nil {
+			return Info{}, err
+		}
+		tkhd, ok := findMP4Box(boxes, "tkhd")
+		if !ok {
+			continue
+		}
+		data, err := readMP4Box(r, tkhd)
+		if err != nil {
+			return Info{}, err
+		}
+		width, height, err := parseTKHD(data)
+		if err != nil {
+			return Info{}, err
+		}
+		if width > 0 && height > 0 {
+			info.Width, info.Height = width, height
+			return info, nil
+		}
+	}
+	return Info{}, ErrNoVideoTrack
+}
+
+func parseMVHD(b []byte) (time.Duration, error) {
+	var timescale, duration uint64
+	switch {
+	case len(b) >= 20 && b[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(b[12:]))
+		duration = uint64(binary.BigEndian.Uint32(b[16:]))
+	case len(b) >= 32 && b[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(b[20:]))
+		duration = binary.BigEndian.Uint64(b[24:])
+	default:
+		return 0, errors.New("probe: invalid MP4 mvhd box")
+	}
+	if timescale == 0 {
+		return 0, errors.New("probe: MP4 mvhd box has no timescale")
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// parseTKHD returns the track dimensions, stored as 16.16 fixed-point
+// numbers at the end of the box.
+func parseTKHD(b []byte) (int, int, error) {
+	var off int
+	switch {
+	case len(b) >= 84 && b[0] == 0:
+		off = 76
+	case len(b) >= 96 && b[0] == 1:
+		off = 88
+	default:
+		return 0, 0, errors.New("probe: invalid MP4 tkhd box")
+	}
+	width := binary.BigEndian.Uint32(b[off:]) >> 16
+	height := binary.BigEndian.Uint32(b[off+4:]) >> 16
+	return int(width), int(height), nil
+}
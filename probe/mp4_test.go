@@ -0,0 +1,85 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mp4TestBox(typ string, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(b, typ...), body...)
+}
+
+func mp4TestMVHD(timescale, duration uint32) []byte {
+	b := make([]byte, 100)
+	binary.BigEndian.PutUint32(b[12:], timescale)
+	binary.BigEndian.PutUint32(b[16:], duration)
+	return mp4TestBox("mvhd", b)
+}
+
+func mp4TestTKHD(width, height uint32) []byte {
+	b := make([]byte, 84)
+	binary.BigEndian.PutUint32(b[76:], width<<16)
+	binary.BigEndian.PutUint32(b[80:], height<<16)
+	return mp4TestBox("tkhd", b)
+}
+
+// testMP4 returns an MP4 file with an audio and a video track, followed by
+// size bytes of media data.
+func testMP4(duration time.Duration, width, height uint32, size int) []byte {
+	return bytes.Join([][]byte{
+		mp4TestBox("ftyp", []byte("isom\x00\x00\x02\x00isomiso2avc1mp41")),
+		mp4TestBox("moov",
+			mp4TestMVHD(1000, uint32(duration.Milliseconds())),
+			mp4TestBox("trak", mp4TestTKHD(0, 0)),
+			mp4TestBox("trak", mp4TestTKHD(width, height)),
+		),
+		mp4TestBox("mdat", make([]byte, size)),
+	}, nil)
+}
+
+func TestProbeMP4(t *testing.T) {
+	data := testMP4(15*time.Second, 640, 360, 1000)
+	info, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, info.MIMEType, "video/mp4")
+	assert.Equal(t, info.Duration, 15*time.Second)
+	assert.Equal(t, info.Width, 640)
+	assert.Equal(t, info.Height, 360)
+	assert.Equal(t, info.Size, int64(len(data)))
+	assert.Equal(t, info.Bitrate, 1)
+}
+
+func TestProbeMP4LargeSize(t *testing.T) {
+	mdat := binary.BigEndian.AppendUint32(nil, 1)
+	mdat = append(mdat, "mdat"...)
+	mdat = binary.BigEndian.AppendUint64(mdat, 16+4)
+	mdat = append(mdat, 0, 0, 0, 0)
+	data := append(mp4TestBox("ftyp", []byte("isom")), mdat...)
+	data = append(data, mp4TestBox("moov", mp4TestMVHD(90000, 90000*30), mp4TestBox("trak", mp4TestTKHD(1280, 720)))...)
+
+	info, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Duration, 30*time.Second)
+	assert.Equal(t, info.Width, 1280)
+	assert.Equal(t, info.Height, 720)
+}
+
+func TestProbeMP4Errors(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"no moov":     mp4TestBox("ftyp", []byte("isom")),
+		"no mvhd":     append(mp4TestBox("ftyp", []byte("isom")), mp4TestBox("moov")...),
+		"no video":    append(mp4TestBox("ftyp", []byte("isom")), mp4TestBox("moov", mp4TestMVHD(1000, 1000), mp4TestBox("trak", mp4TestTKHD(0, 0)))...),
+		"truncated":   testMP4(time.Second, 640, 360, 100)[:200],
+		"invalid box": append(mp4TestBox("ftyp", []byte("isom")), 0, 0, 0, 4, 'm', 'o', 'o', 'v'),
+		"overflow":    append(mp4TestBox("ftyp", []byte("isom")), 0, 0, 0, 1, 'm', 'd', 'a', 't', 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF),
+	} {
+		_, err := Probe(bytes.NewReader(data), int64(len(data)))
+		assert.Error(t, err, name)
+	}
+}
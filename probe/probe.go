@@ -0,0 +1,157 @@
+// Package probe reads the headers of MP4 and WebM assets to verify the
+// MediaFile attributes and Duration declared in VAST documents.
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+var (
+	ErrUnknownFormat = errors.New("probe: unknown container format")
+	ErrNoVideoTrack  = errors.New("probe: no video track")
+)
+
+// Info describes an asset.
+type Info struct {
+	// MIMEType is video/mp4 or video/webm.
+	MIMEType string
+	Duration time.Duration
+	Width    int
+	Height   int
+	// Size is the size of the asset in bytes.
+	Size int64
+	// Bitrate is the average bitrate of the asset in Kbps, as in
+	// MediaFile.Bitrate.
+	Bitrate int
+}
+
+// Probe reads the headers of the size bytes asset in r. Only the headers
+// are read, so r may fetch the asset lazily, e.g. a RangeReader.
+func Probe(r io.ReaderAt, size int64) (Info, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if n < len(head) {
+		if err == nil || err == io.EOF {
+			err = ErrUnknownFormat
+		}
+		return Info{}, err
+	}
+
+	var info Info
+	switch {
+	case string(head[4:8]) == "ftyp":
+		info, err = probeMP4(r, size)
+	case binary.BigEndian.Uint32(head) == ebmlHeaderID:
+		info, err = probeWebM(r, size)
+	default:
+		return Info{}, ErrUnknownFormat
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	info.Size = size
+	if info.Duration > 0 {
+		info.Bitrate = int(math.Round(float64(size) * 8 / info.Duration.Seconds() / 1000))
+	}
+	return info, nil
+}
+
+// ProbeFile probes the asset stored in the file at path.
+func ProbeFile(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return Info{}, err
+	}
+	return Probe(f, st.Size())
+}
+
+// Mismatch is a MediaFile attribute, or the Duration, whose declared value
+// does not match the asset.
+type Mismatch struct {
+	// Field is the MediaFile attribute name or "Duration".
+	Field    string
+	Declared string
+	Actual   string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: declared %s, actual %s", m.Field, m.Declared, m.Actual)
+}
+
+type Options struct {
+	// BitrateTolerance is the accepted relative difference between the
+	// declared and the average bitrate, 0.2 if 0.
+	BitrateTolerance float64
+	// DurationTolerance is the accepted difference between the declared
+	// and the actual duration, one second if 0.
+	DurationTolerance time.Duration
+}
+
+// Check compares the attributes of m, and the Duration of linear if it is
+// not nil, with info. Attributes which are not declared are not checked.
+func Check(m vast2.MediaFile, linear *vast2.Linear, info Info, opts Options) []Mismatch {
+	bitrateTolerance := opts.BitrateTolerance
+	if bitrateTolerance == 0 {
+		bitrateTolerance = 0.2
+	}
+	durationTolerance := opts.DurationTolerance
+	if durationTolerance == 0 {
+		durationTolerance = time.Second
+	}
+
+	var res []Mismatch
+	if typ := strings.TrimSpace(m.Type); typ != "" && !strings.EqualFold(typ, info.MIMEType) {
+		res = append(res, Mismatch{Field: "type", Declared: typ, Actual: info.MIMEType})
+	}
+	if m.Width != 0 && m.Width != info.Width {
+		res = append(res, Mismatch{Field: "width", Declared: strconv.Itoa(m.Width), Actual: strconv.Itoa(info.Width)})
+	}
+	if m.Height != 0 && m.Height != info.Height {
+		res = append(res, Mismatch{Field: "height", Declared: strconv.Itoa(m.Height), Actual: strconv.Itoa(info.Height)})
+	}
+	if m.Bitrate != 0 && info.Bitrate != 0 {
+		diff := math.Abs(float64(m.Bitrate-info.Bitrate)) / float64(info.Bitrate)
+		if diff > bitrateTolerance {
+			res = append(res, Mismatch{Field: "bitrate", Declared: strconv.Itoa(m.Bitrate), Actual: strconv.Itoa(info.Bitrate)})
+		}
+	}
+	if linear != nil && strings.TrimSpace(linear.Duration) != "" {
+		d, err := vast2.ParseDuration(linear.Duration)
+		diff := d - info.Duration
+		if err != nil || diff > durationTolerance || -diff > durationTolerance {
+			res = append(res, Mismatch{
+				Field:    "Duration",
+				Declared: strings.TrimSpace(linear.Duration),
+				Actual:   vast2.FormatDuration(info.Duration.Round(time.Millisecond)),
+			})
+		}
+	}
+	return res
+}
+
+// readAt reads n bytes at off in r.
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if m, err := r.ReadAt(b, off); m < n {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeUnknownFormat(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("short"), []byte("<VAST version=\"2.0\"></VAST>")} {
+		_, err := Probe(bytes.NewReader(data), int64(len(data)))
+		assert.Equal(t, err, ErrUnknownFormat)
+	}
+}
+
+func TestProbeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ad.mp4")
+	assert.NoError(t, os.WriteFile(path, testMP4(10*time.Second, 640, 360, 0), 0o644))
+	info, err := ProbeFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Duration, 10*time.Second)
+	assert.Equal(t, info.Width, 640)
+
+	_, err = ProbeFile(filepath.Join(t.TempDir(), "missing.mp4"))
+	assert.Error(t, err)
+}
+
+func TestRangeReader(t *testing.T) {
+	data := testWebM("webm", 15*time.Second, 640, 360, 1<<16)
+	var requested int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		http.ServeContent(rec, r, "ad.webm", time.Time{}, bytes.NewReader(data))
+		requested += int64(rec.Body.Len())
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	defer ts.Close()
+
+	r, err := NewRangeReader(context.Background(), ts.Client(), ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, r.Size(), int64(len(data)))
+	info, err := Probe(r, r.Size())
+	assert.NoError(t, err)
+	assert.Equal(t, info.Duration, 15*time.Second)
+	assert.Equal(t, info.Width, 640)
+	assert.Equal(t, info.Height, 360)
+	assert.Less(t, requested, int64(1024))
+
+	buf := make([]byte, 10)
+	n, err := r.ReadAt(buf, r.Size()-4)
+	assert.Equal(t, n, 4)
+	assert.Equal(t, err, io.EOF)
+}
+
+func TestRangeReaderUnsupported(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no ranges"))
+	}))
+	defer ts.Close()
+
+	_, err := NewRangeReader(context.Background(), ts.Client(), ts.URL)
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	info := Info{MIMEType: "video/mp4", Duration: 15 * time.Second, Width: 640, Height: 360, Bitrate: 500}
+	m := vast2.MediaFile{Type: "video/mp4", Width: 640, Height: 360, Bitrate: 550}
+	assert.Nil(t, Check(m, &vast2.Linear{Duration: "00:00:15.500"}, info, Options{}))
+	assert.Nil(t, Check(vast2.MediaFile{}, nil, info, Options{}))
+
+	m = vast2.MediaFile{Type: "video/webm", Width: 1280, Height: 720, Bitrate: 1000}
+	assert.Equal(t, Check(m, &vast2.Linear{Duration: "00:00:30"}, info, Options{}), []Mismatch{
+		{Field: "type", Declared: "video/webm", Actual: "video/mp4"},
+		{Field: "width", Declared: "1280", Actual: "640"},
+		{Field: "height", Declared: "720", Actual: "360"},
+		{Field: "bitrate", Declared: "1000", Actual: "500"},
+		{Field: "Duration", Declared: "00:00:30", Actual: "00:00:15"},
+	})
+
+	m = vast2.MediaFile{Bitrate: 1000}
+	assert.Nil(t, Check(m, &vast2.Linear{Duration: "00:00:17"}, info, Options{BitrateTolerance: 1, DurationTolerance: 2 * time.Second}))
+	assert.Equal(t, Check(m, &vast2.Linear{Duration: "15s"}, info, Options{BitrateTolerance: 1}), []Mismatch{
+		{Field: "Duration", Declared: "15s", Actual: "00:00:15"},
+	})
+	assert.Equal(t, Mismatch{Field: "width", Declared: "1280", Actual: "640"}.String(), "width: declared 1280, actual 640")
+}
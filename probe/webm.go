@@ -0,0 +1,243 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Matroska element IDs, with their length marker.
+const (
+	ebmlHeaderID     = 0x1A45DFA3
+	docTypeID        = 0x4282
+	segmentID        = 0x18538067
+	infoID           = 0x1549A966
+	timecodeScaleID  = 0x2AD7B1
+	durationID       = 0x4489
+	tracksID         = 0x1654AE6B
+	trackEntryID     = 0xAE
+	trackTypeID      = 0x83
+	videoID          = 0xE0
+	pixelWidthID     = 0xB0
+	pixelHeightID    = 0xBA
+	videoTrackType   = 1
+	defaultTimescale = 1000000
+)
+
+// maxDocTypeSize bounds the DocType read, "webm" and "matroska" being the
+// only ones defined.
+const maxDocTypeSize = 16
+
+// unknownSize marks elements whose size is not known, e.g. live streams.
+const unknownSize = -1
+
+type ebmlElement struct {
+	id uint32
+	// off and end delimit the content of the element.
+	off, end int64
+}
+
+// readVint reads the variable size integer at off, returning its value with
+// the length marker if raw is set, and its length.
+func readVint(r io.ReaderAt, off int64, raw bool) (uint64, int, error) {
+	first, err := readAt(r, off, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	n := bits.LeadingZeros8(first[0]) + 1
+	if n > 8 {
+		return 0, 0, fmt.Errorf("probe: invalid EBML integer at %d", off)
+	}
+	b, err := readAt(r, off, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	if !raw {
+		v &^= 1 << (7 * n)
+	}
+	return v, n, nil
+}
+
+// ebmlElements returns the elements between off and end.
+func ebmlElements(r io.ReaderAt, off, end int64) ([]ebmlElement, error) {
+	var elems []ebmlElement
+	for off < end {
+		id, n, err := readVint(r, off, true)
+		if err != nil {
+			return nil, err
+		}
+		size, m, err := readVint(r, off+int64(n), false)
+		if err != nil {
+			return nil, err
+		}
+		start := off + int64(n+m)
+		elemEnd := end
+		if size != 1<<(7*m)-1 {
+			elemEnd = start + int64(size)
+		}
+		if elemEnd > end || elemEnd < start {
+			return nil, fmt.Errorf("probe: invalid EBML element %X at %d", id, off)
+		}
+		elems = append(elems, ebmlElement{id: uint32(id), off: start, end: elemEnd})
+		off = elemEnd
+	}
+	return elems, nil
+}
+
+func findEBML(elems []ebmlElement, id uint32) (ebmlElement, bool) {
+	for _, e := range elems {
+		if e.id == id {
+			return e, true
+		}
+	}
+	return ebmlElement{}, false
+}
+
+func readEBMLUint(r io.ReaderAt, e ebmlElement) (uint64, error) {
+	if e.end-e.off > 8 {
+		return 0, fmt.Errorf("probe: invalid EBML integer %X", e.id)
+	}
+	b, err := readAt(r, e.off, int(e.end-e.off))
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func readEBMLFloat(r io.ReaderAt, e ebmlElement) (float64, error) {
+	if n := e.end - e.off; n != 4 && n != 8 {
+		return 0, fmt.Errorf("probe: invalid EBML float %X", e.id)
+	}
+	b, err := readAt(r, e.off, int(e.end-e.off))
+	if err != nil {
+		return 0, err
+	}
+	if len(b) == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+}
+
+// probeWebM reads the duration from Segment/Info and the dimensions from the
+// first video TrackEntry. Clusters are skipped without being read.
+func probeWebM(r io.ReaderAt, size int64) (Info, error) {
+	top, err := ebmlElements(r, 0, size)
+	if err != nil {
+		return Info{}, err
+	}
+	header, ok := findEBML(top, ebmlHeaderID)
+	if !ok {
+		return Info{}, ErrUnknownFormat
+	}
+	headers, err := ebmlElements(r, header.off, header.end)
+	if err != nil {
+		return Info{}, err
+	}
+	if docType, ok := findEBML(headers, docTypeID); ok {
+		if docType.end-docType.off > maxDocTypeSize {
+			return Info{}, errors.New("probe: invalid Matroska DocType")
+		}
+		b, err := readAt(r, docType.off, int(docType.end-docType.off))
+		if err != nil {
+			return Info{}, err
+		}
+		if string(b) != "webm" {
+			return Info{}, errors.New("probe: unsupported Matroska DocType")
+		}
+	}
+
+	segment, ok := findEBML(top, segmentID)
+	if !ok {
+		return Info{}, errors.New("probe: WebM has no Segment")
+	}
+	children, err := ebmlElements(r, segment.off, segment.end)
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{MIMEType: "video/webm"}
+	segInfo, ok := findEBML(children, infoID)
+	if !ok {
+		return Info{}, errors.New("probe: WebM has no Info")
+	}
+	fields, err := ebmlElements(r, segInfo.off, segInfo.end)
+	if err != nil {
+		return Info{}, err
+	}
+	timescale := uint64(defaultTimescale)
+	if e, ok := findEBML(fields, timecodeScaleID); ok {
+		if timescale, err = readEBMLUint(r, e); err != nil {
+			return Info{}, err
+		}
+	}
+	if e, ok := findEBML(fields, durationID); ok {
+		d, err := readEBMLFloat(r, e)
+		if err != nil {
+			return Info{}, err
+		}
+		info.Duration = time.Duration(d * float64(timescale))
+	}
+
+	tracks, ok := findEBML(children, tracksID)
+	if !ok {
+		return Info{}, ErrNoVideoTrack
+	}
+	entries, err := ebmlElements(r, tracks.off, tracks.end)
+	if err != nil {
+		return Info{}, err
+	}
+	for _, entry := range entries {
+		if entry.id != trackEntryID {
+			continue
+		}
+		fields, err := ebmlElements(r, entry.off, entry.end)
+		if err != nil {
+			return Info{}, err
+		}
+		typ, ok := findEBML(fields, trackTypeID)
+		if !ok {
+			continue
+		}
+		if t, err := readEBMLUint(r, typ); err != nil || t != videoTrackType {
+			continue
+		}
+		video, ok := findEBML(fields, videoID)
+		if !ok {
+			continue
+		}
+		dims, err := ebmlElements(r, video.off, video.end)
+		if err != nil {
+			return Info{}, err
+		}
+		for _, d := range dims {
+			var dim *int
+			switch d.id {
+			case pixelWidthID:
+				dim = &info.Width
+			case pixelHeightID:
+				dim = &info.Height
+			default:
+				continue
+			}
+			v, err := readEBMLUint(r, d)
+			if err != nil {
+				return Info{}, err
+			}
+			*dim = int(v)
+		}
+		return info, nil
+	}
+	return Info{}, ErrNoVideoTrack
+}
@@ -0,0 +1,155 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ebmlTestElement encodes an element with an 8 bytes size.
+func ebmlTestElement(id uint32, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	var b []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if c := byte(id >> shift); c != 0 || len(b) > 0 {
+			b = append(b, c)
+		}
+	}
+	// The size has a 0x01 marker followed by 7 bytes.
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	size[0] = 0x01
+	return append(append(b, size...), body...)
+}
+
+func ebmlTestUint(id uint32, v uint64) []byte {
+	return ebmlTestElement(id, binary.BigEndian.AppendUint64(nil, v))
+}
+
+// testWebM returns a WebM file with an audio and a video track, followed by
+// a cluster of size bytes.
+func testWebM(docType string, duration time.Duration, width, height uint64, size int) []byte {
+	ms := float64(duration.Milliseconds())
+	return bytes.Join([][]byte{
+		ebmlTestElement(ebmlHeaderID, ebmlTestElement(docTypeID, []byte(docType))),
+		ebmlTestElement(segmentID,
+			ebmlTestElement(infoID,
+				ebmlTestUint(timecodeScaleID, 1000000),
+				ebmlTestElement(durationID, binary.BigEndian.AppendUint64(nil, math.Float64bits(ms))),
+			),
+			ebmlTestElement(tracksID,
+				ebmlTestElement(trackEntryID, ebmlTestUint(trackTypeID, 2)),
+				ebmlTestElement(trackEntryID,
+					ebmlTestUint(trackTypeID, videoTrackType),
+					ebmlTestElement(videoID, ebmlTestUint(pixelWidthID, width), ebmlTestUint(pixelHeightID, height)),
+				),
+			),
+			ebmlTestElement(0x1F43B675, make([]byte, size)),
+		),
+	}, nil)
+}
+
+func TestProbeWebM(t *testing.T) {
+	data := testWebM("webm", 30*time.Second, 1920, 1080, 3000)
+	info, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, info.MIMEType, "video/webm")
+	assert.Equal(t, info.Duration, 30*time.Second)
+	assert.Equal(t, info.Width, 1920)
+	assert.Equal(t, info.Height, 1080)
+	assert.Equal(t, info.Size, int64(len(data)))
+}
+
+func TestProbeWebMFloat32Duration(t *testing.T) {
+	data := bytes.Join([][]byte{
+		ebmlTestElement(ebmlHeaderID, ebmlTestElement(docTypeID, []byte("webm"))),
+		ebmlTestElement(segmentID,
+			ebmlTestElement(infoID,
+				ebmlTestElement(durationID, binary.BigEndian.AppendUint32(nil, math.Float32bits(2500))),
+			),
+			ebmlTestElement(tracksID, ebmlTestElement(trackEntryID,
+				ebmlTestUint(trackTypeID, videoTrackType),
+				ebmlTestElement(videoID, ebmlTestUint(pixelWidthID, 320), ebmlTestUint(pixelHeightID, 240)),
+			)),
+		),
+	}, nil)
+	info, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Duration, 2500*time.Millisecond)
+	assert.Equal(t, info.Width, 320)
+}
+
+func TestProbeWebMColour(t *testing.T) {
+	// Colour is a master element, as written by ffmpeg for BT.709 output.
+	colour := ebmlTestElement(0x55B0, ebmlTestUint(0x55B1, 1), ebmlTestUint(0x55B9, 1))
+	data := bytes.Join([][]byte{
+		ebmlTestElement(ebmlHeaderID, ebmlTestElement(docTypeID, []byte("webm"))),
+		ebmlTestElement(segmentID,
+			ebmlTestElement(infoID),
+			ebmlTestElement(tracksID, ebmlTestElement(trackEntryID,
+				ebmlTestUint(trackTypeID, videoTrackType),
+				ebmlTestElement(videoID, ebmlTestUint(pixelWidthID, 1280), colour, ebmlTestUint(pixelHeightID, 720)),
+			)),
+		),
+	}, nil)
+	info, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Width, 1280)
+	assert.Equal(t, info.Height, 720)
+}
+
+func TestProbeWebMErrors(t *testing.T) {
+	data := testWebM("matroska", time.Second, 640, 360, 0)
+	_, err := Probe(bytes.NewReader(data), int64(len(data)))
+	assert.Error(t, err)
+
+	data = bytes.Join([][]byte{
+		ebmlTestElement(ebmlHeaderID, ebmlTestElement(docTypeID, []byte("webm"))),
+		ebmlTestElement(segmentID, ebmlTestElement(infoID)),
+	}, nil)
+	_, err = Probe(bytes.NewReader(data), int64(len(data)))
+	assert.Equal(t, err, ErrNoVideoTrack)
+
+	data = testWebM("webm", time.Second, 640, 360, 0)
+	_, err = Probe(bytes.NewReader(data[:40]), 40)
+	assert.Error(t, err)
+}
+
+// smallReader fails the test on reads of more than 64 bytes.
+type smallReader struct {
+	t *testing.T
+	r *bytes.Reader
+}
+
+func (r smallReader) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) > 64 {
+		r.t.Fatalf("read of %d bytes at %d", len(p), off)
+	}
+	return r.r.ReadAt(p, off)
+}
+
+func TestProbeWebMHugeElements(t *testing.T) {
+	// ebmlTestHeader encodes the header of an element of the given size.
+	ebmlTestHeader := func(id []byte, size uint64) []byte {
+		b := binary.BigEndian.AppendUint64(id, size)
+		b[len(id)] = 0x01
+		return b
+	}
+	const huge = 1 << 40
+	data := append(ebmlTestHeader([]byte{0x1A, 0x45, 0xDF, 0xA3}, huge-12), ebmlTestHeader([]byte{0x42, 0x82}, huge-22)...)
+	_, err := Probe(smallReader{t, bytes.NewReader(data)}, huge)
+	assert.EqualError(t, err, "probe: invalid Matroska DocType")
+
+	r := smallReader{t, bytes.NewReader(data)}
+	_, err = readEBMLFloat(r, ebmlElement{id: durationID, end: huge})
+	assert.EqualError(t, err, "probe: invalid EBML float 4489")
+	_, err = readEBMLUint(r, ebmlElement{id: pixelWidthID, end: huge})
+	assert.EqualError(t, err, "probe: invalid EBML integer B0")
+
+	data = testWebM("webmwebmwebmwebmwebm", time.Second, 640, 360, 0)
+	_, err = Probe(bytes.NewReader(data), int64(len(data)))
+	assert.EqualError(t, err, "probe: invalid Matroska DocType")
+}
@@ -0,0 +1,281 @@
+package vast2
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultMaxWrapperDepth = 5
+
+// DefaultFetchTimeout bounds the collapsed fetches of a Resolver with a
+// Cache.
+const DefaultFetchTimeout = 10 * time.Second
+
+var (
+	ErrNoAd         = errors.New("vast2: response has no Ad")
+	ErrWrapperLimit = errors.New("vast2: wrapper limit reached")
+)
+
+// StatusError is returned for tag responses with a status other than 200
+// and for beacon responses with an error status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("vast2: %s: unexpected status %d", e.URL, e.StatusCode)
+}
+
+// Hop describes the fetch of a single tag URL.
+type Hop struct {
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+	VAST       *VAST
+	Err        error
+	// Cached is set if the response was read from the Resolver Cache.
+	Cached bool
+}
+
+// AdSystem returns the AdSystem of the first Ad of the response, if any.
+func (h Hop) AdSystem() string {
+	if h.VAST == nil || len(h.VAST.Ad) == 0 {
+		return ""
+	}
+	ad := h.VAST.Ad[0]
+	switch {
+	case ad.InLine != nil:
+		return strings.TrimSpace(ad.InLine.AdSystem.Data)
+	case ad.Wrapper != nil:
+		return strings.TrimSpace(ad.Wrapper.AdSystem.Data)
+	}
+	return ""
+}
+
+// Resolver fetches tags and follows Wrapper VASTAdTagURI chains.
+type Resolver struct {
+	// Client is used for the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// MaxDepth is the number of wrappers followed before giving up,
+	// DefaultMaxWrapperDepth if 0.
+	MaxDepth int
+	// Cache, if set, stores the responses which allow it with their
+	// Cache-Control or Expires headers, for the ClientInfo they were fetched
+	// with. Concurrent fetches of the same URL with the same ClientInfo are
+	// then collapsed into one request, which keeps the values of the context
+	// of the first caller but outlives its cancellation: each caller stops
+	// waiting when its own context is done.
+	Cache Cache
+	// FetchTimeout bounds the collapsed requests, DefaultFetchTimeout if 0.
+	FetchTimeout time.Duration
+
+	flights flightGroup
+}
+
+func (r *Resolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+func (r *Resolver) fetchTimeout() time.Duration {
+	if r.FetchTimeout > 0 {
+		return r.FetchTimeout
+	}
+	return DefaultFetchTimeout
+}
+
+func (r *Resolver) maxDepth() int {
+	if r.MaxDepth > 0 {
+		return r.MaxDepth
+	}
+	return DefaultMaxWrapperDepth
+}
+
+// Fetch requests and decodes the tag at url, or reads it from the Cache.
+// The request has the headers of the ClientInfo of ctx, if any, and its
+// response is only read from the Cache for the same ClientInfo.
+func (r *Resolver) Fetch(ctx context.Context, url string) Hop {
+	if r.Cache == nil {
+		hop, _ := r.fetch(ctx, url)
+		return hop
+	}
+	start := time.Now()
+	client, _ := ClientInfoFromContext(ctx)
+	key := cacheKey(url, client)
+	if v, ok := r.Cache.Get(key); ok {
+		return Hop{URL: url, StatusCode: http.StatusOK, Duration: time.Since(start), VAST: v.Clone(), Cached: true}
+	}
+	hop := r.flights.do(ctx, key, func() Hop {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.fetchTimeout())
+		defer cancel()
+		hop, header := r.fetch(ctx, url)
+		if hop.Err == nil {
+			if expires, ok := cacheExpiry(header, time.Now()); ok {
+				r.Cache.Set(key, hop.VAST, expires)
+			}
+		}
+		return hop
+	})
+	// The decoded document is shared by the callers and the Cache.
+	if hop.VAST != nil {
+		hop.VAST = hop.VAST.Clone()
+	}
+	hop.URL = url
+	hop.Duration = time.Since(start)
+	return hop
+}
+
+// fetch requests and decodes the tag at url and returns the response
+// header, if any.
+func (r *Resolver) fetch(ctx context.Context, url string) (hop Hop, header http.Header) {
+	hop.URL = url
+	start := time.Now()
+	defer func() { hop.Duration = time.Since(start) }()
+
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		hop.Err = err
+		return hop, nil
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		hop.Err = err
+		return hop, nil
+	}
+	defer resp.Body.Close()
+
+	hop.StatusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		hop.Err = &StatusError{URL: url, StatusCode: resp.StatusCode}
+		return hop, resp.Header
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		hop.Err = err
+		return hop, resp.Header
+	}
+	var v VAST
+	if err := xml.Unmarshal(data, &v); err != nil {
+		hop.Err = fmt.Errorf("vast2: %s: %w", url, err)
+		return hop, resp.Header
+	}
+	hop.VAST = &v
+	return hop, resp.Header
+}
+
+// ResolveAd follows the wrapper chain of ad and returns an InLine Ad with the
+// tracking of every wrapper merged in. ad itself is not modified. The hops
+// are returned even if the resolution fails.
+func (r *Resolver) ResolveAd(ctx context.Context, ad *Ad) (*Ad, []Hop, error) {
+	if ad.InLine != nil || ad.Wrapper == nil {
+		c := ad.Clone()
+		return &c, nil, nil
+	}
+
+	var hops []Hop
+	wrappers := []*Wrapper{ad.Wrapper}
+	for {
+		if len(wrappers) > r.maxDepth() {
+			return nil, hops, ErrWrapperLimit
+		}
+		url := strings.TrimSpace(wrappers[len(wrappers)-1].VASTAdTagURI)
+		hop := r.Fetch(ctx, url)
+		hops = append(hops, hop)
+		if hop.Err != nil {
+			return nil, hops, hop.Err
+		}
+
+		next := firstAd(hop.VAST)
+		if next == nil {
+			return nil, hops, ErrNoAd
+		}
+		if next.Wrapper != nil && next.InLine == nil {
+			wrappers = append(wrappers, next.Wrapper)
+			continue
+		}
+
+		resolved := next.Clone()
+		resolved.ID = ad.ID
+		for i := len(wrappers) - 1; i >= 0; i-- {
+			MergeWrapper(resolved.InLine, wrappers[i])
+		}
+		return &resolved, hops, nil
+	}
+}
+
+func firstAd(v *VAST) *Ad {
+	if v == nil {
+		return nil
+	}
+	for i := range v.Ad {
+		if v.Ad[i].InLine != nil || v.Ad[i].Wrapper != nil {
+			return &v.Ad[i]
+		}
+	}
+	return nil
+}
+
+// MergeWrapper adds the impressions, tracking events, click trackers,
+// companions, extensions and Error URLs of wrapper to inLine.
+func MergeWrapper(inLine *InLine, wrapper *Wrapper) {
+	wrapper = wrapper.Clone()
+	inLine.Error = append(inLine.Error, wrapper.Error...)
+	inLine.Impression = append(inLine.Impression, wrapper.Impression...)
+
+	for _, wc := range wrapper.Creatives.Creative {
+		switch {
+		case wc.Linear != nil:
+			for i := range inLine.Creatives.Creative {
+				if linear := inLine.Creatives.Creative[i].Linear; linear != nil {
+					mergeLinear(linear, wc.Linear)
+				}
+			}
+		case wc.NonLinearAds != nil:
+			for i := range inLine.Creatives.Creative {
+				if nonLinearAds := inLine.Creatives.Creative[i].NonLinearAds; nonLinearAds != nil {
+					nonLinearAds.TrackingEvents = mergeTrackingEvents(nonLinearAds.TrackingEvents, wc.NonLinearAds.TrackingEvents)
+				}
+			}
+		case wc.CompanionAds != nil:
+			inLine.Creatives.Creative = append(inLine.Creatives.Creative, wc)
+		}
+	}
+
+	if wrapper.Extensions != nil {
+		if inLine.Extensions == nil {
+			inLine.Extensions = &Extensions{}
+		}
+		inLine.Extensions.Extension = append(inLine.Extensions.Extension, wrapper.Extensions.Extension...)
+	}
+}
+
+func mergeLinear(linear, wrapper *Linear) {
+	linear.TrackingEvents = mergeTrackingEvents(linear.TrackingEvents, wrapper.TrackingEvents)
+	if wrapper.VideoClicks == nil {
+		return
+	}
+	if linear.VideoClicks == nil {
+		linear.VideoClicks = &VideoClicks{}
+	}
+	linear.VideoClicks.ClickTracking = append(linear.VideoClicks.ClickTracking, wrapper.VideoClicks.ClickTracking...)
+}
+
+func mergeTrackingEvents(events, wrapper *TrackingEvents) *TrackingEvents {
+	if wrapper == nil || len(wrapper.Tracking) == 0 {
+		return events
+	}
+	if events == nil {
+		events = &TrackingEvents{}
+	}
+	events.Tracking = append(events.Tracking, wrapper.Tracking...)
+	return events
+}
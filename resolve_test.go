@@ -0,0 +1,167 @@
+package vast2
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testWrapperAd(id, tagURI string) Ad {
+	return Ad{
+		ID: id,
+		Wrapper: &Wrapper{
+			VASTAdTagURI: tagURI,
+			AdSystem:     AdSystem{Data: "wrapper-" + id},
+			Error:        []string{"http://err.com/" + id},
+			Impression:   []Impression{{Data: "http://imp.com/" + id}},
+			Creatives: Creatives{Creative: []Creative{
+				{Linear: &Linear{
+					TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "start", Data: "http://start.com/" + id}}},
+					VideoClicks:    &VideoClicks{ClickTracking: []ClickTracking{{Data: "http://click.com/" + id}}},
+				}},
+				{NonLinearAds: &NonLinearAds{
+					TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "expand", Data: "http://expand.com/" + id}}},
+				}},
+				{CompanionAds: &CompanionAds{Companion: []Companion{{ID: "companion-" + id}}}},
+			}},
+			Extensions: &Extensions{Extension: []Extension{{Type: "wrapper", Data: []byte(id)}}},
+		},
+	}
+}
+
+func testInLineAd() Ad {
+	return Ad{
+		ID: "inline",
+		InLine: &InLine{
+			AdTitle:    "Title",
+			AdSystem:   AdSystem{Data: "partner"},
+			Impression: []Impression{{Data: "http://imp.com/inline"}},
+			Creatives: Creatives{Creative: []Creative{
+				{Linear: &Linear{
+					Duration:   "00:00:15",
+					MediaFiles: MediaFiles{MediaFile: []MediaFile{{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: "http://media.com/a.mp4"}}},
+				}},
+			}},
+		},
+	}
+}
+
+func serveVAST(t *testing.T, v *VAST) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := xml.Marshal(v)
+		assert.Nil(t, err)
+		w.Write(data)
+	}
+}
+
+func TestResolverFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", serveVAST(t, &VAST{Version: "2.0", Ad: []Ad{testInLineAd()}}))
+	mux.HandleFunc("/invalid", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<VAST"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := &Resolver{}
+	hop := r.Fetch(context.Background(), server.URL+"/ok")
+	assert.Nil(t, hop.Err)
+	assert.Equal(t, hop.StatusCode, 200)
+	assert.Equal(t, hop.AdSystem(), "partner")
+	assert.True(t, hop.Duration > 0)
+
+	hop = r.Fetch(context.Background(), server.URL+"/missing")
+	assert.Equal(t, hop.Err, &StatusError{URL: server.URL + "/missing", StatusCode: 404})
+	assert.Equal(t, hop.StatusCode, 404)
+	assert.Nil(t, hop.VAST)
+
+	hop = r.Fetch(context.Background(), server.URL+"/invalid")
+	assert.NotNil(t, hop.Err)
+	assert.Equal(t, hop.StatusCode, 200)
+}
+
+func TestResolverResolveAd(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/w2", serveVAST(t, &VAST{Version: "2.0", Ad: []Ad{testWrapperAd("w2", server.URL+"/inline")}}))
+	mux.HandleFunc("/inline", serveVAST(t, &VAST{Version: "2.0", Ad: []Ad{testInLineAd()}}))
+
+	ad := testWrapperAd("w1", server.URL+"/w2")
+	resolved, hops, err := (&Resolver{}).ResolveAd(context.Background(), &ad)
+	assert.Nil(t, err)
+	assert.Equal(t, len(hops), 2)
+	assert.Equal(t, hops[0].URL, server.URL+"/w2")
+	assert.Equal(t, hops[0].AdSystem(), "wrapper-w2")
+	assert.Equal(t, hops[1].AdSystem(), "partner")
+	assert.Equal(t, ad, testWrapperAd("w1", server.URL+"/w2"))
+
+	inLine := resolved.InLine
+	assert.Equal(t, resolved.ID, "w1")
+	assert.Nil(t, resolved.Wrapper)
+	assert.Equal(t, inLine.Error, []string{"http://err.com/w2", "http://err.com/w1"})
+	assert.Equal(t, inLine.Impression, []Impression{
+		{Data: "http://imp.com/inline"}, {Data: "http://imp.com/w2"}, {Data: "http://imp.com/w1"},
+	})
+
+	creatives := inLine.Creatives.Creative
+	assert.Equal(t, len(creatives), 3)
+	assert.Equal(t, creatives[0].Linear.TrackingEvents.Tracking, []Tracking{
+		{Event: "start", Data: "http://start.com/w2"}, {Event: "start", Data: "http://start.com/w1"},
+	})
+	assert.Equal(t, creatives[0].Linear.VideoClicks.ClickTracking, []ClickTracking{
+		{Data: "http://click.com/w2"}, {Data: "http://click.com/w1"},
+	})
+	assert.Equal(t, creatives[1].CompanionAds.Companion[0].ID, "companion-w2")
+	assert.Equal(t, creatives[2].CompanionAds.Companion[0].ID, "companion-w1")
+	assert.Equal(t, len(inLine.Extensions.Extension), 2)
+}
+
+func TestResolverResolveInLine(t *testing.T) {
+	ad := testInLineAd()
+	resolved, hops, err := (&Resolver{}).ResolveAd(context.Background(), &ad)
+	assert.Nil(t, err)
+	assert.Nil(t, hops)
+	assert.Equal(t, resolved, &ad)
+}
+
+func TestResolverResolveErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/empty", serveVAST(t, &VAST{Version: "2.0"}))
+	mux.HandleFunc("/loop", serveVAST(t, &VAST{Version: "2.0", Ad: []Ad{testWrapperAd("loop", server.URL+"/loop")}}))
+
+	ad := testWrapperAd("w", server.URL+"/empty")
+	_, hops, err := (&Resolver{}).ResolveAd(context.Background(), &ad)
+	assert.Equal(t, err, ErrNoAd)
+	assert.Equal(t, len(hops), 1)
+
+	ad = testWrapperAd("w", server.URL+"/loop")
+	_, hops, err = (&Resolver{MaxDepth: 3}).ResolveAd(context.Background(), &ad)
+	assert.Equal(t, err, ErrWrapperLimit)
+	assert.Equal(t, len(hops), 3)
+
+	ad = testWrapperAd("w", server.URL+"/missing")
+	_, _, err = (&Resolver{}).ResolveAd(context.Background(), &ad)
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, err.Error(), fmt.Sprintf("vast2: %s/missing: unexpected status 404", server.URL))
+}
+
+func TestMergeWrapperErrors(t *testing.T) {
+	inLine := testInLineAd().InLine
+	inLine.Error = []string{"http://err.com/inline"}
+	wrapper := testWrapperAd("w", "http://tag.com").Wrapper
+	MergeWrapper(inLine, wrapper)
+	assert.Equal(t, inLine.Error, []string{"http://err.com/inline", "http://err.com/w"})
+	assert.Equal(t, wrapper, testWrapperAd("w", "http://tag.com").Wrapper)
+}
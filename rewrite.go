@@ -0,0 +1,52 @@
+package vast2
+
+type URLKind int
+
+const (
+	URLImpression URLKind = iota
+	URLTracking
+	URLClickThrough
+	URLClickTracking
+	URLCustomClick
+	URLError
+	URLSurvey
+	URLMediaFile
+	URLStaticResource
+	URLIFrameResource
+	URLCompanionClickThrough
+	URLNonLinearClickThrough
+	URLVASTAdTagURI
+)
+
+var urlKindNames = [...]string{
+	URLImpression:            "Impression",
+	URLTracking:              "Tracking",
+	URLClickThrough:          "ClickThrough",
+	URLClickTracking:         "ClickTracking",
+	URLCustomClick:           "CustomClick",
+	URLError:                 "Error",
+	URLSurvey:                "Survey",
+	URLMediaFile:             "MediaFile",
+	URLStaticResource:        "StaticResource",
+	URLIFrameResource:        "IFrameResource",
+	URLCompanionClickThrough: "CompanionClickThrough",
+	URLNonLinearClickThrough: "NonLinearClickThrough",
+	URLVASTAdTagURI:          "VASTAdTagURI",
+}
+
+func (k URLKind) String() string {
+	if k < 0 || int(k) >= len(urlKindNames) {
+		return "Unknown"
+	}
+	return urlKindNames[k]
+}
+
+// RewriteURLs calls fn for every non-empty URL in v, with its kind and its
+// location, and replaces the URL with the returned value.
+func RewriteURLs(v *VAST, fn func(p Path, kind URLKind, url string) string) {
+	Walk(v, Visitor{
+		URL: func(p Path, kind URLKind, url *string) {
+			*url = fn(p, kind, *url)
+		},
+	})
+}
@@ -0,0 +1,113 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLKindString(t *testing.T) {
+	assert.Equal(t, URLImpression.String(), "Impression")
+	assert.Equal(t, URLVASTAdTagURI.String(), "VASTAdTagURI")
+	assert.Equal(t, URLKind(-1).String(), "Unknown")
+	assert.Equal(t, URLKind(100).String(), "Unknown")
+}
+
+func TestRewriteURLsNil(t *testing.T) {
+	RewriteURLs(nil, func(p Path, kind URLKind, url string) string {
+		t.Fatal("unexpected call")
+		return url
+	})
+}
+
+func TestRewriteURLs(t *testing.T) {
+	vast := &VAST{
+		Version: "2.0",
+		Ad: []Ad{
+			{
+				ID: "1",
+				InLine: &InLine{
+					Survey:     "http://survey.com",
+					Error:      []string{"http://err.com"},
+					Impression: []Impression{{Data: "http://imp.com"}},
+					Creatives: Creatives{Creative: []Creative{
+						{
+							Linear: &Linear{
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "start", Data: "http://start.com"}}},
+								VideoClicks: &VideoClicks{
+									ClickThrough:  "http://click.com",
+									ClickTracking: []ClickTracking{{Data: "http://ct.com"}},
+									CustomClick:   []CustomClick{{Data: "http://custom.com"}},
+								},
+								MediaFiles: MediaFiles{MediaFile: []MediaFile{{Data: "http://media.com/a.mp4"}}},
+							},
+						},
+						{
+							CompanionAds: &CompanionAds{Companion: []Companion{{
+								IFrameResource:        "http://iframe.com",
+								CompanionClickThrough: "http://cct.com",
+								StaticResource:        &StaticResource{Data: "http://static.com/a.png"},
+								TrackingEvents:        &TrackingEvents{Tracking: []Tracking{{Event: "creativeView", Data: "http://cv.com"}}},
+							}}},
+						},
+						{
+							NonLinearAds: &NonLinearAds{
+								NonLinear: []NonLinear{{
+									IFrameResource:        "http://nl-iframe.com",
+									NonLinearClickThrough: "http://nlct.com",
+									StaticResource:        &StaticResource{Data: "http://nl-static.com/a.png"},
+								}},
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "expand", Data: "http://expand.com"}}},
+							},
+						},
+					}},
+				},
+			},
+			{
+				ID: "2",
+				Wrapper: &Wrapper{
+					VASTAdTagURI: "http://tag.com",
+					Error:        []string{"http://wrapper-err.com"},
+					Impression:   []Impression{{Data: "http://wrapper-imp.com"}, {}},
+				},
+			},
+		},
+	}
+
+	var kinds []URLKind
+	RewriteURLs(vast, func(p Path, kind URLKind, url string) string {
+		kinds = append(kinds, kind)
+		return "http://proxy.com/?u=" + url + "&at=" + p.String()
+	})
+
+	assert.Equal(t, kinds, []URLKind{
+		URLSurvey, URLError, URLImpression,
+		URLTracking, URLClickThrough, URLClickTracking, URLCustomClick, URLMediaFile,
+		URLIFrameResource, URLCompanionClickThrough, URLStaticResource, URLTracking,
+		URLIFrameResource, URLNonLinearClickThrough, URLStaticResource, URLTracking,
+		URLVASTAdTagURI, URLError, URLImpression,
+	})
+
+	inLine := vast.Ad[0].InLine
+	assert.Equal(t, inLine.Impression[0].Data, "http://proxy.com/?u=http://imp.com&at=Ad[0]/InLine/Impression[0]")
+	assert.Equal(t, inLine.Creatives.Creative[0].Linear.VideoClicks.ClickTracking[0].Data, "http://proxy.com/?u=http://ct.com&at=Ad[0]/InLine/Creatives/Creative[0]/Linear/VideoClicks/ClickTracking[0]")
+	assert.Equal(t, inLine.Creatives.Creative[1].CompanionAds.Companion[0].StaticResource.Data, "http://proxy.com/?u=http://static.com/a.png&at=Ad[0]/InLine/Creatives/Creative[1]/CompanionAds/Companion[0]/StaticResource")
+
+	wrapper := vast.Ad[1].Wrapper
+	assert.Equal(t, wrapper.VASTAdTagURI, "http://proxy.com/?u=http://tag.com&at=Ad[1]/Wrapper/VASTAdTagURI")
+	assert.Equal(t, wrapper.Impression[1].Data, "")
+
+	data, err := xml.Marshal(wrapper)
+	assert.Nil(t, err)
+
+	res := `<Wrapper>` +
+		`<VASTAdTagURI>http://proxy.com/?u=http://tag.com&amp;at=Ad[1]/Wrapper/VASTAdTagURI</VASTAdTagURI>` +
+		`<Error>http://proxy.com/?u=http://wrapper-err.com&amp;at=Ad[1]/Wrapper/Error[0]</Error>` +
+		`<AdSystem></AdSystem>` +
+		`<Impression>http://proxy.com/?u=http://wrapper-imp.com&amp;at=Ad[1]/Wrapper/Impression[0]</Impression>` +
+		`<Impression></Impression>` +
+		`<Creatives></Creatives>` +
+		`</Wrapper>`
+	assert.Equal(t, string(data), res)
+}
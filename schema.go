@@ -15,7 +15,7 @@ type InLine struct {
 	AdTitle     string       `xml:"AdTitle"`
 	Description string       `xml:"Description,omitempty"`
 	Survey      string       `xml:"Survey,omitempty"`
-	Error       string       `xml:"Error,omitempty"`
+	Error       []string     `xml:"Error,omitempty"`
 	AdSystem    AdSystem     `xml:"AdSystem"`
 	Impression  []Impression `xml:"Impression,omitempty"`
 	Creatives   Creatives    `xml:"Creatives"`
@@ -46,11 +46,12 @@ type Creative struct {
 }
 
 type Linear struct {
-	Duration       string          `xml:"Duration"`
-	AdParameters   string          `xml:"AdParameters,omitempty"`
-	TrackingEvents *TrackingEvents `xml:"TrackingEvents,omitempty"`
-	VideoClicks    *VideoClicks    `xml:"VideoClicks,omitempty"`
-	MediaFiles     MediaFiles      `xml:"MediaFiles"`
+	Duration           string              `xml:"Duration"`
+	AdParameters       string              `xml:"AdParameters,omitempty"`
+	TrackingEvents     *TrackingEvents     `xml:"TrackingEvents,omitempty"`
+	VideoClicks        *VideoClicks        `xml:"VideoClicks,omitempty"`
+	MediaFiles         MediaFiles          `xml:"MediaFiles"`
+	CreativeExtensions *CreativeExtensions `xml:"CreativeExtensions,omitempty"`
 }
 
 type TrackingEvents struct {
@@ -63,9 +64,14 @@ type Tracking struct {
 }
 
 type VideoClicks struct {
-	ClickThrough  string       `xml:"ClickThrough,omitempty"`
-	ClickTracking []string     `xml:"ClickTracking,omitempty"`
-	CustomClick   *CustomClick `xml:"CustomClick,omitempty"`
+	ClickThrough  string          `xml:"ClickThrough,omitempty"`
+	ClickTracking []ClickTracking `xml:"ClickTracking,omitempty"`
+	CustomClick   []CustomClick   `xml:"CustomClick,omitempty"`
+}
+
+type ClickTracking struct {
+	ID   string `xml:"id,attr,omitempty"`
+	Data string `xml:",chardata"`
 }
 
 type CustomClick struct {
@@ -95,19 +101,20 @@ type CompanionAds struct {
 }
 
 type Companion struct {
-	ID                    string          `xml:"id,attr,omitempty"`
-	Width                 int             `xml:"width,attr"`
-	Height                int             `xml:"height,attr"`
-	ExpandedWidth         int             `xml:"expandedWidth,attr,omitempty"`
-	ExpandedHeight        int             `xml:"expandedHeight,attr,omitempty"`
-	ApiFramework          string          `xml:"apiFramework,attr,omitempty"`
-	IFrameResource        string          `xml:"IFrameResource,omitempty"`
-	HTMLResource          string          `xml:"HTMLResource,omitempty"`
-	CompanionClickThrough string          `xml:"CompanionClickThrough,omitempty"`
-	AltText               string          `xml:"AltText,omitempty"`
-	AdParameters          string          `xml:"AdParameters,omitempty"`
-	StaticResource        *StaticResource `xml:"StaticResource,omitempty"`
-	TrackingEvents        *TrackingEvents `xml:"TrackingEvents,omitempty"`
+	ID                    string              `xml:"id,attr,omitempty"`
+	Width                 int                 `xml:"width,attr"`
+	Height                int                 `xml:"height,attr"`
+	ExpandedWidth         int                 `xml:"expandedWidth,attr,omitempty"`
+	ExpandedHeight        int                 `xml:"expandedHeight,attr,omitempty"`
+	ApiFramework          string              `xml:"apiFramework,attr,omitempty"`
+	IFrameResource        string              `xml:"IFrameResource,omitempty"`
+	HTMLResource          string              `xml:"HTMLResource,omitempty"`
+	CompanionClickThrough string              `xml:"CompanionClickThrough,omitempty"`
+	AltText               string              `xml:"AltText,omitempty"`
+	AdParameters          string              `xml:"AdParameters,omitempty"`
+	StaticResource        *StaticResource     `xml:"StaticResource,omitempty"`
+	TrackingEvents        *TrackingEvents     `xml:"TrackingEvents,omitempty"`
+	CreativeExtensions    *CreativeExtensions `xml:"CreativeExtensions,omitempty"`
 }
 
 type StaticResource struct {
@@ -121,19 +128,21 @@ type NonLinearAds struct {
 }
 
 type NonLinear struct {
-	ID                    string          `xml:"id,attr,omitempty"`
-	Width                 int             `xml:"width,attr"`
-	Height                int             `xml:"height,attr"`
-	ExpandedWidth         int             `xml:"expandedWidth,attr,omitempty"`
-	ExpandedHeight        int             `xml:"expandedHeight,attr,omitempty"`
-	Scalable              bool            `xml:"scalable,attr,omitempty"`
-	MaintainAspectRatio   bool            `xml:"maintainAspectRatio,attr,omitempty"`
-	ApiFramework          string          `xml:"apiFramework,attr,omitempty"`
-	IFrameResource        string          `xml:"IFrameResource,omitempty"`
-	HTMLResource          string          `xml:"HTMLResource,omitempty"`
-	AdParameters          string          `xml:"AdParameters,omitempty"`
-	NonLinearClickThrough string          `xml:"NonLinearClickThrough,omitempty"`
-	StaticResource        *StaticResource `xml:"StaticResource,omitempty"`
+	ID                    string              `xml:"id,attr,omitempty"`
+	Width                 int                 `xml:"width,attr"`
+	Height                int                 `xml:"height,attr"`
+	ExpandedWidth         int                 `xml:"expandedWidth,attr,omitempty"`
+	ExpandedHeight        int                 `xml:"expandedHeight,attr,omitempty"`
+	Scalable              bool                `xml:"scalable,attr,omitempty"`
+	MaintainAspectRatio   bool                `xml:"maintainAspectRatio,attr,omitempty"`
+	ApiFramework          string              `xml:"apiFramework,attr,omitempty"`
+	MinSuggestedDuration  string              `xml:"minSuggestedDuration,attr,omitempty"`
+	IFrameResource        string              `xml:"IFrameResource,omitempty"`
+	HTMLResource          string              `xml:"HTMLResource,omitempty"`
+	AdParameters          string              `xml:"AdParameters,omitempty"`
+	NonLinearClickThrough string              `xml:"NonLinearClickThrough,omitempty"`
+	StaticResource        *StaticResource     `xml:"StaticResource,omitempty"`
+	CreativeExtensions    *CreativeExtensions `xml:"CreativeExtensions,omitempty"`
 }
 
 type Extensions struct {
@@ -145,9 +154,18 @@ type Extension struct {
 	Data []byte `xml:",innerxml"`
 }
 
+type CreativeExtensions struct {
+	CreativeExtension []CreativeExtension `xml:"CreativeExtension"`
+}
+
+type CreativeExtension struct {
+	Type string `xml:"type,attr,omitempty"`
+	Data []byte `xml:",innerxml"`
+}
+
 type Wrapper struct {
 	VASTAdTagURI string       `xml:"VASTAdTagURI"`
-	Error        string       `xml:"Error,omitempty"`
+	Error        []string     `xml:"Error,omitempty"`
 	AdSystem     AdSystem     `xml:"AdSystem"`
 	Impression   []Impression `xml:"Impression,omitempty"`
 	Creatives    Creatives    `xml:"Creatives"`
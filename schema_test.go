@@ -11,7 +11,7 @@ func TestVASTDefault(t *testing.T) {
 	vast := VAST{}
 	data, err := xml.Marshal(vast)
 	assert.Nil(t, err)
-	assert.Equal(t, string(data), `<VAST version=""></VAST>`)
+	assert.Equal(t, string(data), `<VAST version="2.0"></VAST>`)
 }
 
 func TestVASTWithAttrs(t *testing.T) {
@@ -50,7 +50,7 @@ func TestAdWithAttrs(t *testing.T) {
 }
 
 func TestAdWithNestedObjects(t *testing.T) {
-	ad := Ad{ID: "123", InLine: &InLine{}, Wrapper: &Wrapper{}}
+	ad := Ad{ID: "123", InLine: &InLine{}}
 	data, err := xml.Marshal(ad)
 	assert.Nil(t, err)
 
@@ -58,6 +58,14 @@ func TestAdWithNestedObjects(t *testing.T) {
 		`<InLine>` +
 		`<AdTitle></AdTitle><AdSystem></AdSystem><Creatives></Creatives>` +
 		`</InLine>` +
+		`</Ad>`
+	assert.Equal(t, string(data), res)
+
+	ad = Ad{ID: "123", Wrapper: &Wrapper{}}
+	data, err = xml.Marshal(ad)
+	assert.Nil(t, err)
+
+	res = `<Ad id="123">` +
 		`<Wrapper>` +
 		`<VASTAdTagURI></VASTAdTagURI><AdSystem></AdSystem><Creatives></Creatives>` +
 		`</Wrapper>` +
@@ -77,7 +85,7 @@ func TestInLineWithAttrs(t *testing.T) {
 		AdTitle:     "AdName",
 		Description: "Desc",
 		Survey:      "http://survey.com",
-		Error:       "http://err.com",
+		Error:       []string{"http://err.com", "http://err2.com"},
 	}
 
 	data, err := xml.Marshal(inLine)
@@ -85,7 +93,8 @@ func TestInLineWithAttrs(t *testing.T) {
 
 	res := `<InLine>` +
 		`<AdTitle>AdName</AdTitle><Description>Desc</Description>` +
-		`<Survey>http://survey.com</Survey><Error>http://err.com</Error>` +
+		`<Survey>http://survey.com</Survey>` +
+		`<Error>http://err.com</Error><Error>http://err2.com</Error>` +
 		`<AdSystem></AdSystem><Creatives></Creatives>` +
 		`</InLine>`
 	assert.Equal(t, string(data), res)
@@ -164,7 +173,7 @@ func TestCreativeWithAttrs(t *testing.T) {
 }
 
 func TestCreativeWithNestedObjects(t *testing.T) {
-	creative := Creative{Linear: &Linear{}, CompanionAds: &CompanionAds{}, NonLinearAds: &NonLinearAds{}}
+	creative := Creative{Linear: &Linear{}}
 	data, err := xml.Marshal(creative)
 	assert.Nil(t, err)
 
@@ -172,10 +181,18 @@ func TestCreativeWithNestedObjects(t *testing.T) {
 		`<Linear>` +
 		`<Duration></Duration><MediaFiles></MediaFiles>` +
 		`</Linear>` +
-		`<CompanionAds></CompanionAds>` +
-		`<NonLinearAds></NonLinearAds>` +
 		`</Creative>`
 	assert.Equal(t, string(data), res)
+
+	creative = Creative{CompanionAds: &CompanionAds{}}
+	data, err = xml.Marshal(creative)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<Creative><CompanionAds></CompanionAds></Creative>`)
+
+	creative = Creative{NonLinearAds: &NonLinearAds{}}
+	data, err = xml.Marshal(creative)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<Creative><NonLinearAds></NonLinearAds></Creative>`)
 }
 
 func TestLinearDefault(t *testing.T) {
@@ -211,6 +228,19 @@ func TestLinearWithNestedObjects(t *testing.T) {
 	assert.Equal(t, string(data), res)
 }
 
+func TestLinearWithCreativeExtensions(t *testing.T) {
+	linear := Linear{CreativeExtensions: &CreativeExtensions{}}
+	data, err := xml.Marshal(linear)
+	assert.Nil(t, err)
+
+	res := `<Linear>` +
+		`<Duration></Duration>` +
+		`<MediaFiles></MediaFiles>` +
+		`<CreativeExtensions></CreativeExtensions>` +
+		`</Linear>`
+	assert.Equal(t, string(data), res)
+}
+
 func TestTrackingEventsDefault(t *testing.T) {
 	events := TrackingEvents{}
 	data, err := xml.Marshal(events)
@@ -253,24 +283,38 @@ func TestVideoClicksDefault(t *testing.T) {
 func TestVideoClicksWithAttrs(t *testing.T) {
 	clicks := VideoClicks{
 		ClickThrough:  "http://clk.com",
-		ClickTracking: []string{"http://tr.com", "http://tr2.com"},
+		ClickTracking: []ClickTracking{{ID: "1", Data: "http://tr.com"}, {Data: "http://tr2.com"}},
 	}
 	data, err := xml.Marshal(clicks)
 	assert.Nil(t, err)
 
 	res := `<VideoClicks>` +
 		`<ClickThrough>http://clk.com</ClickThrough>` +
-		`<ClickTracking>http://tr.com</ClickTracking>` +
+		`<ClickTracking id="1">http://tr.com</ClickTracking>` +
 		`<ClickTracking>http://tr2.com</ClickTracking>` +
 		`</VideoClicks>`
 	assert.Equal(t, string(data), res)
 }
 
 func TestVideoClicksWithNestedObjects(t *testing.T) {
-	clicks := VideoClicks{CustomClick: &CustomClick{}}
+	clicks := VideoClicks{CustomClick: []CustomClick{{}, {}}}
 	data, err := xml.Marshal(clicks)
 	assert.Nil(t, err)
-	assert.Equal(t, string(data), `<VideoClicks><CustomClick></CustomClick></VideoClicks>`)
+	assert.Equal(t, string(data), `<VideoClicks><CustomClick></CustomClick><CustomClick></CustomClick></VideoClicks>`)
+}
+
+func TestClickTrackingDefault(t *testing.T) {
+	click := ClickTracking{}
+	data, err := xml.Marshal(click)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<ClickTracking></ClickTracking>`)
+}
+
+func TestClickTrackingWithAttrs(t *testing.T) {
+	click := ClickTracking{ID: "1", Data: "http://clk.com"}
+	data, err := xml.Marshal(click)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<ClickTracking id="1">http://clk.com</ClickTracking>`)
 }
 
 func TestCustomClickDefault(t *testing.T) {
@@ -391,8 +435,9 @@ func TestCompanionWithAttrs(t *testing.T) {
 
 func TestCompanionWithNestedObjects(t *testing.T) {
 	companion := Companion{
-		StaticResource: &StaticResource{},
-		TrackingEvents: &TrackingEvents{},
+		StaticResource:     &StaticResource{},
+		TrackingEvents:     &TrackingEvents{},
+		CreativeExtensions: &CreativeExtensions{},
 	}
 	data, err := xml.Marshal(companion)
 	assert.Nil(t, err)
@@ -400,6 +445,7 @@ func TestCompanionWithNestedObjects(t *testing.T) {
 	res := `<Companion width="0" height="0">` +
 		`<StaticResource creativeType=""></StaticResource>` +
 		`<TrackingEvents></TrackingEvents>` +
+		`<CreativeExtensions></CreativeExtensions>` +
 		`</Companion>`
 
 	assert.Equal(t, string(data), res)
@@ -460,6 +506,7 @@ func TestNonLinearWithAttrs(t *testing.T) {
 		Scalable:              true,
 		MaintainAspectRatio:   true,
 		ApiFramework:          "js",
+		MinSuggestedDuration:  "00:00:15",
 		IFrameResource:        "http://iframe.com",
 		HTMLResource:          "<body></body>",
 		AdParameters:          "a=1",
@@ -469,7 +516,7 @@ func TestNonLinearWithAttrs(t *testing.T) {
 	assert.Nil(t, err)
 	res := `<NonLinear id="a1" width="300" height="250" ` +
 		`expandedWidth="350" expandedHeight="300" scalable="true" ` +
-		`maintainAspectRatio="true" apiFramework="js">` +
+		`maintainAspectRatio="true" apiFramework="js" minSuggestedDuration="00:00:15">` +
 		`<IFrameResource>http://iframe.com</IFrameResource>` +
 		`<HTMLResource>&lt;body&gt;&lt;/body&gt;</HTMLResource>` +
 		`<AdParameters>a=1</AdParameters>` +
@@ -481,13 +528,15 @@ func TestNonLinearWithAttrs(t *testing.T) {
 
 func TestNonLinearWithNestedObjects(t *testing.T) {
 	ad := NonLinear{
-		StaticResource: &StaticResource{},
+		StaticResource:     &StaticResource{},
+		CreativeExtensions: &CreativeExtensions{},
 	}
 	data, err := xml.Marshal(ad)
 	assert.Nil(t, err)
 
 	res := `<NonLinear width="0" height="0">` +
 		`<StaticResource creativeType=""></StaticResource>` +
+		`<CreativeExtensions></CreativeExtensions>` +
 		`</NonLinear>`
 	assert.Equal(t, string(data), res)
 }
@@ -515,6 +564,59 @@ func TestExtensions(t *testing.T) {
 	assert.Equal(t, string(data), res)
 }
 
+func TestCreativeExtensions(t *testing.T) {
+	ext := CreativeExtensions{
+		CreativeExtension: []CreativeExtension{
+			{
+				Type: "skip",
+				Data: []byte("<Offset>5</Offset>"),
+			},
+			{
+				Data: []byte("<Brand>acme</Brand>"),
+			},
+		},
+	}
+	data, err := xml.Marshal(ext)
+	assert.Nil(t, err)
+
+	res := `<CreativeExtensions>` +
+		`<CreativeExtension type="skip"><Offset>5</Offset></CreativeExtension>` +
+		`<CreativeExtension><Brand>acme</Brand></CreativeExtension>` +
+		`</CreativeExtensions>`
+	assert.Equal(t, string(data), res)
+}
+
+func TestDecodeMultiValuedFields(t *testing.T) {
+	data := `<VAST version="2.0"><Ad><InLine>` +
+		`<Error>http://err.com/1</Error><Error>http://err.com/2</Error>` +
+		`<Creatives><Creative><Linear>` +
+		`<VideoClicks>` +
+		`<ClickTracking id="a">http://ct.com/a</ClickTracking>` +
+		`<ClickTracking>http://ct.com/b</ClickTracking>` +
+		`<CustomClick id="x">http://cc.com/x</CustomClick>` +
+		`<CustomClick id="y">http://cc.com/y</CustomClick>` +
+		`</VideoClicks>` +
+		`<CreativeExtensions><CreativeExtension type="skip"><Offset>5</Offset></CreativeExtension></CreativeExtensions>` +
+		`</Linear></Creative></Creatives>` +
+		`</InLine></Ad></VAST>`
+
+	var vast VAST
+	assert.Nil(t, xml.Unmarshal([]byte(data), &vast))
+
+	inLine := vast.Ad[0].InLine
+	assert.Equal(t, inLine.Error, []string{"http://err.com/1", "http://err.com/2"})
+	linear := inLine.Creatives.Creative[0].Linear
+	assert.Equal(t, linear.VideoClicks.ClickTracking, []ClickTracking{
+		{ID: "a", Data: "http://ct.com/a"}, {Data: "http://ct.com/b"},
+	})
+	assert.Equal(t, linear.VideoClicks.CustomClick, []CustomClick{
+		{ID: "x", Data: "http://cc.com/x"}, {ID: "y", Data: "http://cc.com/y"},
+	})
+	assert.Equal(t, linear.CreativeExtensions.CreativeExtension, []CreativeExtension{
+		{Type: "skip", Data: []byte("<Offset>5</Offset>")},
+	})
+}
+
 func TestWrapperDefault(t *testing.T) {
 	wrapper := Wrapper{}
 	data, err := xml.Marshal(wrapper)
@@ -530,7 +632,7 @@ func TestWrapperDefault(t *testing.T) {
 func TestWrapperWithAttrs(t *testing.T) {
 	wrapper := Wrapper{
 		VASTAdTagURI: "http://tag.com",
-		Error:        "http://error.com",
+		Error:        []string{"http://error.com"},
 	}
 	data, err := xml.Marshal(wrapper)
 	assert.Nil(t, err)
@@ -0,0 +1,394 @@
+package ssai
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+var ErrNoMediaFile = errors.New("ssai: Ad has no playable MediaFile")
+
+// TrackingScheme is the schemeIdUri of the EventStreams carrying the beacons
+// of ad Periods. The value of a stream is the tracked event and the content
+// of its Events the URLs to request.
+const TrackingScheme = "urn:com:pubnative:vast2:tracking"
+
+type DASHOptions struct {
+	// At is the index of the content Period the ad Periods are inserted
+	// before. The ad Periods are appended if it is the number of Periods.
+	At int
+	// ID prefixes the ids of the ad Periods, "ad" if empty.
+	ID string
+}
+
+type period struct {
+	XMLName       xml.Name        `xml:"Period"`
+	ID            string          `xml:"id,attr"`
+	Start         string          `xml:"start,attr,omitempty"`
+	Duration      string          `xml:"duration,attr"`
+	EventStream   []eventStream   `xml:"EventStream"`
+	AdaptationSet []adaptationSet `xml:"AdaptationSet"`
+}
+
+type eventStream struct {
+	SchemeIDURI string  `xml:"schemeIdUri,attr"`
+	Value       string  `xml:"value,attr"`
+	Timescale   int     `xml:"timescale,attr"`
+	Event       []event `xml:"Event"`
+}
+
+type event struct {
+	PresentationTime int64  `xml:"presentationTime,attr"`
+	ID               int    `xml:"id,attr"`
+	Data             string `xml:",chardata"`
+}
+
+type adaptationSet struct {
+	MimeType       string           `xml:"mimeType,attr"`
+	Representation []representation `xml:"Representation"`
+}
+
+type representation struct {
+	ID        string `xml:"id,attr"`
+	Bandwidth int    `xml:"bandwidth,attr"`
+	Width     int    `xml:"width,attr,omitempty"`
+	Height    int    `xml:"height,attr,omitempty"`
+	BaseURL   string `xml:"BaseURL"`
+}
+
+// DASHManifest inserts one Period per ad into the content MPD template, as
+// chosen by opts. The mediaPresentationDuration of the MPD and the start of
+// the Periods following the break, if set, are delayed by the duration of
+// the ads. The template is otherwise copied as is. If the template sets the
+// start of its Periods, so do the ad Periods.
+//
+// An ad Period lasts the Duration of the first Linear creative of the ad and
+// has an AdaptationSet per type of playable MediaFile with a bitrate, the
+// others being skipped. Its EventStreams
+// carry the impressions and progress tracking events at their offsets. The
+// output only depends on the arguments.
+func DASHManifest(template []byte, ads []vast2.Ad, opts DASHOptions) ([]byte, error) {
+	prefix := opts.ID
+	if prefix == "" {
+		prefix = "ad"
+	}
+	periods := make([]period, 0, len(ads))
+	durations := make([]time.Duration, 0, len(ads))
+	var total time.Duration
+	for i := range ads {
+		p, d, err := adPeriod(&ads[i])
+		if err != nil {
+			return nil, adError(&ads[i], err)
+		}
+		p.ID = prefix + "-" + strconv.Itoa(i)
+		periods = append(periods, p)
+		durations = append(durations, d)
+		total += d
+	}
+
+	layout, err := scanMPD(template)
+	if err != nil {
+		return nil, err
+	}
+	if opts.At < 0 || opts.At > len(layout.periods) {
+		return nil, fmt.Errorf("ssai: MPD template has %d Periods, cannot insert at %d", len(layout.periods), opts.At)
+	}
+	start, ok, err := insertionStart(layout, opts.At)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		for i := range periods {
+			periods[i].Start = "PT" + seconds(start) + "S"
+			start += durations[i]
+		}
+	}
+	off := layout.end
+	if opts.At < len(layout.periods) {
+		off = layout.periods[opts.At].start
+	}
+	// The Periods are written on their own lines, before the one of the
+	// insertion point.
+	if ls := bytes.LastIndexByte(template[:off], '\n') + 1; ls > 0 && len(bytes.TrimSpace(template[ls:off])) == 0 {
+		off = ls
+	}
+
+	var buf bytes.Buffer
+	pos := 0
+	// shift copies the template up to tag and delays its attr by total.
+	shift := func(tag mpdTag, attr string) error {
+		value, ok := tag.attr(attr)
+		if !ok {
+			return nil
+		}
+		d, err := parseISODuration(value)
+		if err != nil {
+			return fmt.Errorf("ssai: MPD template: %s: %w", attr, err)
+		}
+		buf.Write(template[pos:tag.start])
+		buf.Write(setAttr(template[tag.start:tag.end], attr, "PT"+seconds(d+total)+"S"))
+		pos = tag.end
+		return nil
+	}
+	if err := shift(layout.root, "mediaPresentationDuration"); err != nil {
+		return nil, err
+	}
+	buf.Write(template[pos:off])
+	pos = off
+	for _, p := range periods {
+		data, err := xml.MarshalIndent(p, layout.indent, "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	for _, tag := range layout.periods[opts.At:] {
+		if err := shift(tag, "start"); err != nil {
+			return nil, err
+		}
+	}
+	buf.Write(template[pos:])
+	return buf.Bytes(), nil
+}
+
+// adPeriod returns the Period of ad and its duration.
+func adPeriod(ad *vast2.Ad) (period, time.Duration, error) {
+	linear := firstLinear(ad)
+	if linear == nil {
+		return period{}, 0, ErrNoLinear
+	}
+	duration, err := vast2.ParseDuration(linear.Duration)
+	if err != nil {
+		return period{}, 0, err
+	}
+	p := period{Duration: "PT" + seconds(duration) + "S"}
+
+	for _, b := range Beacons(ad.InLine, linear, duration) {
+		if n := len(p.EventStream); n == 0 || p.EventStream[n-1].Value != b.Event {
+			p.EventStream = append(p.EventStream, eventStream{SchemeIDURI: TrackingScheme, Value: b.Event, Timescale: 1000})
+		}
+		s := &p.EventStream[len(p.EventStream)-1]
+		s.Event = append(s.Event, event{
+			PresentationTime: b.Offset.Milliseconds(),
+			ID:               len(s.Event),
+			Data:             b.URL,
+		})
+	}
+
+	sets := map[string]int{}
+	for i, m := range linear.PlayableMediaFiles() {
+		url := strings.TrimSpace(m.Data)
+		// The bandwidth of Representations is mandatory.
+		if url == "" || m.Bitrate <= 0 {
+			continue
+		}
+		typ := strings.TrimSpace(m.Type)
+		j, ok := sets[typ]
+		if !ok {
+			j = len(p.AdaptationSet)
+			sets[typ] = j
+			p.AdaptationSet = append(p.AdaptationSet, adaptationSet{MimeType: typ})
+		}
+		id := strings.TrimSpace(m.ID)
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+		p.AdaptationSet[j].Representation = append(p.AdaptationSet[j].Representation, representation{
+			ID:        id,
+			Bandwidth: m.Bitrate * 1000,
+			Width:     m.Width,
+			Height:    m.Height,
+			BaseURL:   url,
+		})
+	}
+	if len(p.AdaptationSet) == 0 {
+		return period{}, 0, ErrNoMediaFile
+	}
+	return p, duration, nil
+}
+
+// insertionStart returns the start of the ad Periods inserted before the
+// Period at of layout, if its Periods have a start. The start of a Period
+// without one is the end of the previous Period.
+func insertionStart(layout mpdLayout, at int) (time.Duration, bool, error) {
+	set := false
+	for _, p := range layout.periods {
+		if _, ok := p.attr("start"); ok {
+			set = true
+		}
+	}
+	if !set {
+		return 0, false, nil
+	}
+	parse := func(tag mpdTag, attr string) (time.Duration, bool, error) {
+		v, ok := tag.attr(attr)
+		if !ok {
+			return 0, false, nil
+		}
+		d, err := parseISODuration(v)
+		if err != nil {
+			return 0, false, fmt.Errorf("ssai: MPD template: %s: %w", attr, err)
+		}
+		return d, true, nil
+	}
+	unknown := errors.New("ssai: MPD template: cannot determine the start of the ad Periods")
+
+	var start time.Duration
+	known := true
+	for i, p := range layout.periods {
+		d, ok, err := parse(p, "start")
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			start, known = d, true
+		}
+		if i == at {
+			if !known {
+				return 0, false, unknown
+			}
+			return start, true, nil
+		}
+		d, ok, err = parse(p, "duration")
+		if err != nil {
+			return 0, false, err
+		}
+		start += d
+		known = known && ok
+	}
+	// The ad Periods follow the last one, which ends with the presentation.
+	if !known {
+		d, ok, err := parse(layout.root, "mediaPresentationDuration")
+		if err != nil {
+			return 0, false, err
+		}
+		if !ok {
+			return 0, false, unknown
+		}
+		start = d
+	}
+	return start, true, nil
+}
+
+// mpdTag is a start tag of an MPD template.
+type mpdTag struct {
+	// start and end delimit the tag in the template.
+	start, end int
+	attrs      []xml.Attr
+}
+
+func (t mpdTag) attr(name string) (string, bool) {
+	for _, a := range t.attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// mpdLayout locates the elements of an MPD template DASHManifest changes.
+type mpdLayout struct {
+	root    mpdTag
+	periods []mpdTag
+	// end is the offset of the end tag of the MPD.
+	end int
+	// indent is the indentation of the first Period.
+	indent string
+}
+
+func scanMPD(template []byte) (mpdLayout, error) {
+	var layout mpdLayout
+	dec := xml.NewDecoder(bytes.NewReader(template))
+	depth := 0
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return mpdLayout{}, fmt.Errorf("ssai: MPD template: %w", err)
+		}
+		tag := mpdTag{start: start, end: int(dec.InputOffset())}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			tag.attrs = t.Attr
+			switch {
+			case depth == 0 && t.Name.Local != "MPD":
+				return mpdLayout{}, fmt.Errorf("ssai: MPD template: unexpected root element %s", t.Name.Local)
+			case depth == 0:
+				layout.root = tag
+			case depth == 1 && t.Name.Local == "Period":
+				if len(layout.periods) == 0 {
+					layout.indent = lineIndent(template[:start])
+				}
+				layout.periods = append(layout.periods, tag)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				layout.end = start
+				return layout, nil
+			}
+		}
+	}
+	return mpdLayout{}, errors.New("ssai: MPD template: unexpected EOF")
+}
+
+// setAttr replaces the value of the attribute name in the start tag.
+func setAttr(tag []byte, name, value string) []byte {
+	re := regexp.MustCompile(`\s` + regexp.QuoteMeta(name) + `\s*=\s*("[^"]*"|'[^']*')`)
+	loc := re.FindSubmatchIndex(tag)
+	if loc == nil {
+		return tag
+	}
+	res := make([]byte, 0, len(tag)+len(value))
+	res = append(res, tag[:loc[2]]...)
+	res = append(res, '"')
+	res = append(res, value...)
+	res = append(res, '"')
+	return append(res, tag[loc[3]:]...)
+}
+
+// parseISODuration parses the xs:duration values of MPDs, without years and
+// months which have no fixed length.
+func parseISODuration(s string) (time.Duration, error) {
+	m := isoDurationRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || m[0] == "P" || strings.HasSuffix(m[0], "T") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += time.Duration(f * float64(unit))
+	}
+	return d, nil
+}
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// lineIndent returns the whitespace between the last newline of b and its
+// end, or "" if there is something else.
+func lineIndent(b []byte) string {
+	line := b[bytes.LastIndexByte(b, '\n')+1:]
+	if len(bytes.TrimLeft(line, " \t")) > 0 {
+		return ""
+	}
+	return string(line)
+}
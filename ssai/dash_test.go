@@ -0,0 +1,208 @@
+package ssai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+const testMPD = `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT70S">
+  <Period id="content-0" duration="PT30S">
+    <BaseURL>http://content.com/0/</BaseURL>
+  </Period>
+  <Period id="content-1" duration="PT40S">
+    <BaseURL>http://content.com/1/</BaseURL>
+  </Period>
+</MPD>
+`
+
+func TestDASHManifest(t *testing.T) {
+	ad := testAd("a", "")
+	linear := ad.InLine.Creatives.Creative[1].Linear
+	linear.MediaFiles.MediaFile = []vast2.MediaFile{
+		{ID: "hd", Delivery: "progressive", Type: "video/mp4", Bitrate: 2000, Width: 1280, Height: 720, Data: "http://media.com/a-hd.mp4"},
+		{Delivery: "progressive", Type: "application/javascript", ApiFramework: "VPAID", Data: "http://media.com/a.js"},
+		{Delivery: "progressive", Type: "video/webm", Bitrate: 800, Width: 640, Height: 360, Data: "http://media.com/a.webm"},
+		{Delivery: "progressive", Type: "video/mp4", Bitrate: 800, Width: 640, Height: 360, Data: "http://media.com/a-sd.mp4"},
+	}
+	linear.TrackingEvents.Tracking = linear.TrackingEvents.Tracking[:3]
+	ad.InLine.Impression = append(ad.InLine.Impression, vast2.Impression{Data: "http://imp.com/a2"})
+
+	data, err := DASHManifest([]byte(testMPD), []vast2.Ad{ad}, DASHOptions{At: 1})
+	assert.Nil(t, err)
+
+	res := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT80.000S">
+  <Period id="content-0" duration="PT30S">
+    <BaseURL>http://content.com/0/</BaseURL>
+  </Period>
+  <Period id="ad-0" duration="PT10.000S">
+    <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="impression" timescale="1000">
+      <Event presentationTime="0" id="0">http://imp.com/a</Event>
+      <Event presentationTime="0" id="1">http://imp.com/a2</Event>
+    </EventStream>
+    <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="start" timescale="1000">
+      <Event presentationTime="0" id="0">http://start.com/a</Event>
+    </EventStream>
+    <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="complete" timescale="1000">
+      <Event presentationTime="10000" id="0">http://complete.com/a</Event>
+    </EventStream>
+    <AdaptationSet mimeType="video/mp4">
+      <Representation id="hd" bandwidth="2000000" width="1280" height="720">
+        <BaseURL>http://media.com/a-hd.mp4</BaseURL>
+      </Representation>
+      <Representation id="2" bandwidth="800000" width="640" height="360">
+        <BaseURL>http://media.com/a-sd.mp4</BaseURL>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet mimeType="video/webm">
+      <Representation id="1" bandwidth="800000" width="640" height="360">
+        <BaseURL>http://media.com/a.webm</BaseURL>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+  <Period id="content-1" duration="PT40S">
+    <BaseURL>http://content.com/1/</BaseURL>
+  </Period>
+</MPD>
+`
+	assert.Equal(t, string(data), res)
+}
+
+func TestDASHManifestPeriodStart(t *testing.T) {
+	template := `<MPD type='static' mediaPresentationDuration='PT1M10S'>` + "\n" +
+		`  <Period id="c0" start="PT0S"/>` + "\n" +
+		`  <Period id="c1" start = "PT30S"/>` + "\n" +
+		`  <Period id="c2" start="PT1M" xmlns:x="x" x:start="y"/>` + "\n" +
+		`</MPD>`
+	ad := testAd("a", "")
+	ad.InLine.Impression = nil
+	ad.InLine.Creatives.Creative[1].Linear.TrackingEvents = nil
+	data, err := DASHManifest([]byte(template), []vast2.Ad{ad, ad}, DASHOptions{At: 1})
+	assert.Nil(t, err)
+
+	res := `<MPD type='static' mediaPresentationDuration="PT90.000S">` + "\n" +
+		`  <Period id="c0" start="PT0S"/>` + "\n" +
+		`  <Period id="ad-0" start="PT30.000S" duration="PT10.000S">` + "\n" +
+		`    <AdaptationSet mimeType="video/mp4">` + "\n" +
+		`      <Representation id="0" bandwidth="500000">` + "\n" +
+		`        <BaseURL>http://media.com/a.mp4</BaseURL>` + "\n" +
+		`      </Representation>` + "\n" +
+		`    </AdaptationSet>` + "\n" +
+		`  </Period>` + "\n" +
+		`  <Period id="ad-1" start="PT40.000S" duration="PT10.000S">` + "\n" +
+		`    <AdaptationSet mimeType="video/mp4">` + "\n" +
+		`      <Representation id="0" bandwidth="500000">` + "\n" +
+		`        <BaseURL>http://media.com/a.mp4</BaseURL>` + "\n" +
+		`      </Representation>` + "\n" +
+		`    </AdaptationSet>` + "\n" +
+		`  </Period>` + "\n" +
+		`  <Period id="c1" start = "PT50.000S"/>` + "\n" +
+		`  <Period id="c2" start="PT80.000S" xmlns:x="x" x:start="y"/>` + "\n" +
+		`</MPD>`
+	assert.Equal(t, string(data), res)
+}
+
+func TestDASHManifestAppendedPeriodStart(t *testing.T) {
+	ads := []vast2.Ad{testAd("a", "")}
+	for template, start := range map[string]string{
+		`<MPD mediaPresentationDuration="PT1M"><Period start="PT0S"/></MPD>`:                           "PT60.000S",
+		`<MPD><Period start="PT5S" duration="PT20S"/><Period duration="PT10S"/></MPD>`:                 "PT35.000S",
+		`<MPD mediaPresentationDuration="PT40S"><Period start="PT0S"/><Period duration="PT5S"/></MPD>`: "PT40.000S",
+	} {
+		data, err := DASHManifest([]byte(template), ads, DASHOptions{At: strings.Count(template, "<Period")})
+		assert.Nil(t, err, template)
+		assert.Contains(t, string(data), `<Period id="ad-0" start="`+start+`" duration="PT10.000S">`, template)
+	}
+
+	_, err := DASHManifest([]byte(`<MPD><Period start="PT0S"/><Period/></MPD>`), ads, DASHOptions{At: 2})
+	assert.Equal(t, err.Error(), "ssai: MPD template: cannot determine the start of the ad Periods")
+	_, err = DASHManifest([]byte(`<MPD><Period start="PT0S" duration="1"/></MPD>`), ads, DASHOptions{At: 1})
+	assert.Equal(t, err.Error(), `ssai: MPD template: duration: invalid duration "1"`)
+}
+
+func TestParseISODuration(t *testing.T) {
+	for s, d := range map[string]time.Duration{
+		"PT70S":      70 * time.Second,
+		"PT1H2M3.5S": time.Hour + 2*time.Minute + 3500*time.Millisecond,
+		"P1DT1S":     24*time.Hour + time.Second,
+		" PT0.250S ": 250 * time.Millisecond,
+	} {
+		res, err := parseISODuration(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, res, d, s)
+	}
+	for _, s := range []string{"", "P", "PT", "P1Y", "P1M", "PT-1S", "70S"} {
+		_, err := parseISODuration(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestDASHManifestQuartiles(t *testing.T) {
+	ad := testAd("a", "")
+	ad.InLine.Creatives.Creative[1].Linear.Duration = "00:00:30.5"
+	ad.InLine.Impression = nil
+	data, err := DASHManifest([]byte(`<MPD><Period id="c"/></MPD>`), []vast2.Ad{ad}, DASHOptions{At: 1, ID: "break"})
+	assert.Nil(t, err)
+
+	res := `<MPD><Period id="c"/><Period id="break-0" duration="PT30.500S">` + "\n" +
+		`  <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="start" timescale="1000">` + "\n" +
+		`    <Event presentationTime="0" id="0">http://start.com/a</Event>` + "\n" +
+		`  </EventStream>` + "\n" +
+		`  <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="firstQuartile" timescale="1000">` + "\n" +
+		`    <Event presentationTime="7625" id="0">http://q1.com/a</Event>` + "\n" +
+		`  </EventStream>` + "\n" +
+		`  <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="midpoint" timescale="1000">` + "\n" +
+		`    <Event presentationTime="15250" id="0">http://mid.com/a</Event>` + "\n" +
+		`  </EventStream>` + "\n" +
+		`  <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="thirdQuartile" timescale="1000">` + "\n" +
+		`    <Event presentationTime="22875" id="0">http://q3.com/a</Event>` + "\n" +
+		`  </EventStream>` + "\n" +
+		`  <EventStream schemeIdUri="urn:com:pubnative:vast2:tracking" value="complete" timescale="1000">` + "\n" +
+		`    <Event presentationTime="30500" id="0">http://complete.com/a</Event>` + "\n" +
+		`  </EventStream>` + "\n" +
+		`  <AdaptationSet mimeType="video/mp4">` + "\n" +
+		`    <Representation id="0" bandwidth="500000">` + "\n" +
+		`      <BaseURL>http://media.com/a.mp4</BaseURL>` + "\n" +
+		`    </Representation>` + "\n" +
+		`  </AdaptationSet>` + "\n" +
+		`</Period>` + "\n" +
+		`</MPD>`
+	assert.Equal(t, string(data), res)
+}
+
+func TestDASHManifestErrors(t *testing.T) {
+	ads := []vast2.Ad{testAd("a", "")}
+	_, err := DASHManifest([]byte(testMPD), ads, DASHOptions{At: 3})
+	assert.Equal(t, err.Error(), "ssai: MPD template has 2 Periods, cannot insert at 3")
+	_, err = DASHManifest([]byte(`<VAST/>`), ads, DASHOptions{})
+	assert.Equal(t, err.Error(), "ssai: MPD template: unexpected root element VAST")
+	_, err = DASHManifest([]byte(`<MPD>`), ads, DASHOptions{})
+	assert.NotNil(t, err)
+
+	ads[0].InLine.Creatives.Creative[1].Linear.Duration = "10"
+	_, err = DASHManifest([]byte(testMPD), ads, DASHOptions{})
+	assert.NotNil(t, err)
+
+	ads[0].InLine.Creatives.Creative[1].Linear.Duration = "00:00:10"
+	ads[0].InLine.Creatives.Creative[1].Linear.MediaFiles.MediaFile = nil
+	_, err = DASHManifest([]byte(testMPD), ads, DASHOptions{})
+	assert.Equal(t, err.Error(), `ssai: Ad has no playable MediaFile (Ad "a")`)
+
+	// The bandwidth of a Representation cannot be 0.
+	ads[0].InLine.Creatives.Creative[1].Linear.MediaFiles.MediaFile = []vast2.MediaFile{
+		{Delivery: "progressive", Type: "video/mp4", Data: "http://media.com/a.mp4"},
+	}
+	_, err = DASHManifest([]byte(testMPD), ads, DASHOptions{})
+	assert.True(t, errors.Is(err, ErrNoMediaFile))
+
+	ads = []vast2.Ad{testAd("a", "")}
+	_, err = DASHManifest([]byte(`<MPD mediaPresentationDuration="P1Y"><Period/></MPD>`), ads, DASHOptions{})
+	assert.Equal(t, err.Error(), `ssai: MPD template: mediaPresentationDuration: invalid duration "P1Y"`)
+}
@@ -0,0 +1,152 @@
+package ssai
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+// Markers selects how an ad break is signalled in HLS playlists.
+type Markers int
+
+const (
+	// CueOutIn wraps the break in #EXT-X-CUE-OUT and #EXT-X-CUE-IN.
+	CueOutIn Markers = iota
+	// DateRange announces the break with #EXT-X-DATERANGE.
+	DateRange
+)
+
+// BeaconTag is the playlist tag carrying a Beacon hint. It comes before the
+// segment during which the beacon fires, and its OFFSET attribute is
+// relative to the start of that segment. Players ignore unknown tags.
+const BeaconTag = "#EXT-X-VAST-BEACON"
+
+type HLSOptions struct {
+	// Segments maps MediaFile URLs to the segments they were transcoded
+	// to. The first MediaFile of an ad found in the map is used. See
+	// ParseMediaPlaylist for MediaFiles which are HLS playlists.
+	Segments map[string][]Segment
+	Markers  Markers
+	// ID is the ID of the DATERANGE of the break, generated from StartDate
+	// if empty.
+	ID string
+	// StartDate is the program date and time of the start of the break,
+	// required with DateRange.
+	StartDate time.Time
+}
+
+// HLSPlaylist returns the media playlist fragment playing ads as an ad break,
+// to be spliced between two content segments. Every ad starts with a
+// discontinuity, as does the content following the break, and its segments
+// carry the Beacon hints derived from its impressions and tracking events.
+// The output only depends on the arguments.
+func HLSPlaylist(ads []vast2.Ad, opts HLSOptions) ([]byte, error) {
+	stitched, err := stitch(ads, opts.Segments)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Markers == DateRange && opts.StartDate.IsZero() {
+		return nil, errors.New("ssai: DateRange markers without StartDate")
+	}
+
+	var total time.Duration
+	for _, s := range stitched {
+		total += s.duration
+	}
+
+	var buf bytes.Buffer
+	switch opts.Markers {
+	case CueOutIn:
+		fmt.Fprintf(&buf, "#EXT-X-CUE-OUT:DURATION=%s\n", seconds(total))
+	case DateRange:
+		start := opts.StartDate.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+		id := strings.TrimSpace(opts.ID)
+		if id == "" {
+			id = "break-" + strconv.FormatInt(opts.StartDate.UnixMilli(), 10)
+		}
+		fmt.Fprintf(&buf, "#EXT-X-DATERANGE:ID=%s,CLASS=\"com.pubnative.vast2.break\",START-DATE=\"%s\",DURATION=%s\n",
+			quotedString(id), start, seconds(total))
+		fmt.Fprintf(&buf, "#EXT-X-PROGRAM-DATE-TIME:%s\n", start)
+	}
+	for _, s := range stitched {
+		buf.WriteString("#EXT-X-DISCONTINUITY\n")
+		beacons := segmentBeacons(s.segments, s.beacons)
+		for i, seg := range s.segments {
+			for _, b := range beacons[i] {
+				fmt.Fprintf(&buf, "%s:EVENT=%s,URI=%s,OFFSET=%s\n",
+					BeaconTag, quotedString(b.Event), quotedString(b.URL), seconds(b.Offset))
+			}
+			fmt.Fprintf(&buf, "#EXTINF:%s,\n%s\n", seconds(seg.Duration), seg.URI)
+		}
+	}
+	buf.WriteString("#EXT-X-DISCONTINUITY\n")
+	if opts.Markers == CueOutIn {
+		buf.WriteString("#EXT-X-CUE-IN\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteReplacer percent-encodes the characters a quoted-string cannot hold,
+// which has no escapes (RFC 8216 section 4.2).
+var quoteReplacer = strings.NewReplacer(`"`, "%22", "\r", "%0D", "\n", "%0A")
+
+// quotedString returns s as an HLS quoted-string.
+func quotedString(s string) string {
+	return `"` + quoteReplacer.Replace(s) + `"`
+}
+
+// ParseMediaPlaylist returns the segments of the HLS media playlist data,
+// e.g. a MediaFile of type application/x-mpegURL, with their URIs resolved
+// against the URL of the playlist.
+func ParseMediaPlaylist(data []byte, base string) ([]Segment, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	var segs []Segment
+	var duration time.Duration
+	hasDuration := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		switch {
+		case text == "":
+		case strings.HasPrefix(text, "#EXTINF:"):
+			value := strings.TrimPrefix(text, "#EXTINF:")
+			if i := strings.IndexByte(value, ','); i >= 0 {
+				value = value[:i]
+			}
+			secs, err := strconv.ParseFloat(value, 64)
+			if err != nil || secs < 0 {
+				return nil, fmt.Errorf("ssai: line %d: invalid EXTINF %q", line, value)
+			}
+			duration = time.Duration(secs * float64(time.Second)).Round(time.Millisecond)
+			hasDuration = true
+		case strings.HasPrefix(text, "#"):
+		default:
+			if !hasDuration {
+				return nil, fmt.Errorf("ssai: line %d: segment without EXTINF", line)
+			}
+			ref, err := url.Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("ssai: line %d: %w", line, err)
+			}
+			segs = append(segs, Segment{URI: baseURL.ResolveReference(ref).String(), Duration: duration})
+			hasDuration = false
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		return nil, errors.New("ssai: playlist has no segments")
+	}
+	return segs, nil
+}
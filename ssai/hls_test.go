@@ -0,0 +1,153 @@
+package ssai
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+func testAd(id, mediaURL string) vast2.Ad {
+	return vast2.NewInLineAd(id, &vast2.InLine{
+		AdTitle:    id,
+		AdSystem:   vast2.AdSystem{Data: "test"},
+		Impression: []vast2.Impression{{Data: "http://imp.com/" + id}},
+		Creatives: vast2.Creatives{Creative: []vast2.Creative{
+			vast2.NewCompanionAdsCreative(&vast2.CompanionAds{}),
+			vast2.NewLinearCreative(&vast2.Linear{
+				Duration: "00:00:10",
+				TrackingEvents: &vast2.TrackingEvents{Tracking: []vast2.Tracking{
+					{Event: "complete", Data: "http://complete.com/" + id},
+					{Event: "pause", Data: "http://pause.com/" + id},
+					{Event: "start", Data: "http://start.com/" + id},
+					{Event: "firstQuartile", Data: "http://q1.com/" + id},
+					{Event: "midpoint", Data: "http://mid.com/" + id},
+					{Event: "thirdQuartile", Data: "http://q3.com/" + id},
+				}},
+				MediaFiles: vast2.MediaFiles{MediaFile: []vast2.MediaFile{
+					{Delivery: "progressive", Type: "video/mp4", Bitrate: 500, Data: "http://media.com/" + id + ".mp4"},
+					{Delivery: "streaming", Type: "application/x-mpegURL", Data: mediaURL},
+				}},
+			}),
+		}},
+	})
+}
+
+var testSegments = map[string][]Segment{
+	"http://media.com/a.m3u8": {
+		{URI: "http://media.com/a/0.ts", Duration: 4 * time.Second},
+		{URI: "http://media.com/a/1.ts", Duration: 4 * time.Second},
+		{URI: "http://media.com/a/2.ts", Duration: 2 * time.Second},
+	},
+	"http://media.com/b.mp4": {
+		{URI: "http://media.com/b/0.ts", Duration: 5500 * time.Millisecond},
+	},
+}
+
+func TestHLSPlaylistCueOutIn(t *testing.T) {
+	ads := []vast2.Ad{testAd("a", "http://media.com/a.m3u8"), testAd("b", "http://media.com/b.m3u8")}
+	data, err := HLSPlaylist(ads, HLSOptions{Segments: testSegments})
+	assert.Nil(t, err)
+
+	res := "#EXT-X-CUE-OUT:DURATION=15.500\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		`#EXT-X-VAST-BEACON:EVENT="impression",URI="http://imp.com/a",OFFSET=0.000` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="start",URI="http://start.com/a",OFFSET=0.000` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="firstQuartile",URI="http://q1.com/a",OFFSET=2.500` + "\n" +
+		"#EXTINF:4.000,\nhttp://media.com/a/0.ts\n" +
+		`#EXT-X-VAST-BEACON:EVENT="midpoint",URI="http://mid.com/a",OFFSET=1.000` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="thirdQuartile",URI="http://q3.com/a",OFFSET=3.500` + "\n" +
+		"#EXTINF:4.000,\nhttp://media.com/a/1.ts\n" +
+		`#EXT-X-VAST-BEACON:EVENT="complete",URI="http://complete.com/a",OFFSET=2.000` + "\n" +
+		"#EXTINF:2.000,\nhttp://media.com/a/2.ts\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		`#EXT-X-VAST-BEACON:EVENT="impression",URI="http://imp.com/b",OFFSET=0.000` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="start",URI="http://start.com/b",OFFSET=0.000` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="firstQuartile",URI="http://q1.com/b",OFFSET=1.375` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="midpoint",URI="http://mid.com/b",OFFSET=2.750` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="thirdQuartile",URI="http://q3.com/b",OFFSET=4.125` + "\n" +
+		`#EXT-X-VAST-BEACON:EVENT="complete",URI="http://complete.com/b",OFFSET=5.500` + "\n" +
+		"#EXTINF:5.500,\nhttp://media.com/b/0.ts\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		"#EXT-X-CUE-IN\n"
+	assert.Equal(t, string(data), res)
+
+	again, err := HLSPlaylist(ads, HLSOptions{Segments: testSegments})
+	assert.Nil(t, err)
+	assert.Equal(t, again, data)
+}
+
+func TestHLSPlaylistDateRange(t *testing.T) {
+	ads := []vast2.Ad{testAd("b", "")}
+	ads[0].InLine.Impression = nil
+	ads[0].InLine.Creatives.Creative[1].Linear.TrackingEvents = nil
+	opts := HLSOptions{
+		Segments:  testSegments,
+		Markers:   DateRange,
+		ID:        "break-1",
+		StartDate: time.Date(2026, 10, 19, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+	}
+	data, err := HLSPlaylist(ads, opts)
+	assert.Nil(t, err)
+
+	res := `#EXT-X-DATERANGE:ID="break-1",CLASS="com.pubnative.vast2.break",START-DATE="2026-10-19T10:00:00.000Z",DURATION=5.500` + "\n" +
+		"#EXT-X-PROGRAM-DATE-TIME:2026-10-19T10:00:00.000Z\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		"#EXTINF:5.500,\nhttp://media.com/b/0.ts\n" +
+		"#EXT-X-DISCONTINUITY\n"
+	assert.Equal(t, string(data), res)
+
+	opts.ID = "say \"hi\"\nè"
+	data, err = HLSPlaylist(ads, opts)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `#EXT-X-DATERANGE:ID="say %22hi%22%0Aè",`)
+
+	opts.ID = " "
+	data, err = HLSPlaylist(ads, opts)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `#EXT-X-DATERANGE:ID="break-1792404000000",`)
+
+	opts.StartDate = time.Time{}
+	_, err = HLSPlaylist(ads, opts)
+	assert.NotNil(t, err)
+}
+
+func TestHLSPlaylistErrors(t *testing.T) {
+	_, err := HLSPlaylist([]vast2.Ad{testAd("c", "http://media.com/c.m3u8")}, HLSOptions{Segments: testSegments})
+	assert.True(t, errors.Is(err, ErrNoSegments))
+	assert.Equal(t, err.Error(), `ssai: no segments for the MediaFiles of the Ad (Ad "c")`)
+
+	wrapper := vast2.NewWrapperAd("w", &vast2.Wrapper{})
+	_, err = HLSPlaylist([]vast2.Ad{wrapper}, HLSOptions{Segments: testSegments})
+	assert.True(t, errors.Is(err, ErrNoLinear))
+}
+
+func TestParseMediaPlaylist(t *testing.T) {
+	data := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:4\n" +
+		"#EXTINF:4.0,\n" +
+		"0.ts\n" +
+		"\n" +
+		"#EXTINF:3.9999,title\n" +
+		"/abs/1.ts\n" +
+		"#EXTINF:1\n" +
+		"http://cdn.com/2.ts\n" +
+		"#EXT-X-ENDLIST\n"
+	segs, err := ParseMediaPlaylist([]byte(data), "http://media.com/a/index.m3u8")
+	assert.Nil(t, err)
+	assert.Equal(t, segs, []Segment{
+		{URI: "http://media.com/a/0.ts", Duration: 4 * time.Second},
+		{URI: "http://media.com/abs/1.ts", Duration: 4 * time.Second},
+		{URI: "http://cdn.com/2.ts", Duration: time.Second},
+	})
+
+	_, err = ParseMediaPlaylist([]byte("#EXTM3U\n0.ts\n"), "")
+	assert.Equal(t, err.Error(), "ssai: line 2: segment without EXTINF")
+	_, err = ParseMediaPlaylist([]byte("#EXTINF:x,\n0.ts\n"), "")
+	assert.Equal(t, err.Error(), `ssai: line 1: invalid EXTINF "x"`)
+	_, err = ParseMediaPlaylist([]byte("#EXTM3U\n"), "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,158 @@
+// Package ssai stitches resolved VAST ads into streaming manifests for
+// server-side ad insertion.
+package ssai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+var (
+	ErrNoLinear   = errors.New("ssai: Ad has no Linear creative")
+	ErrNoSegments = errors.New("ssai: no segments for the MediaFiles of the Ad")
+)
+
+// Segment is a media segment of a transcoded ad.
+type Segment struct {
+	URI      string
+	Duration time.Duration
+}
+
+// Beacon is a tracking URL to request once playback reaches Offset from the
+// start of an ad.
+type Beacon struct {
+	Event  string
+	URL    string
+	Offset time.Duration
+}
+
+// beaconEvents are the events fired at a fixed position of an ad, in the
+// order they are fired at the same offset.
+var beaconEvents = []struct {
+	event string
+	// num/den is the position of the event in the ad.
+	num, den time.Duration
+}{
+	{"impression", 0, 1},
+	{"creativeView", 0, 1},
+	{"start", 0, 1},
+	{"firstQuartile", 1, 4},
+	{"midpoint", 1, 2},
+	{"thirdQuartile", 3, 4},
+	{"complete", 1, 1},
+}
+
+// stitchedAd is an ad of a break with its segments and beacons.
+type stitchedAd struct {
+	id       string
+	segments []Segment
+	duration time.Duration
+	beacons  []Beacon
+}
+
+// stitch finds the segments of the first Linear creative of every ad in
+// segments, keyed by MediaFile URL.
+func stitch(ads []vast2.Ad, segments map[string][]Segment) ([]stitchedAd, error) {
+	res := make([]stitchedAd, 0, len(ads))
+	for i := range ads {
+		ad := &ads[i]
+		linear := firstLinear(ad)
+		if linear == nil {
+			return nil, adError(ad, ErrNoLinear)
+		}
+		var segs []Segment
+		for _, m := range linear.MediaFiles.MediaFile {
+			if segs = segments[strings.TrimSpace(m.Data)]; len(segs) > 0 {
+				break
+			}
+		}
+		if len(segs) == 0 {
+			return nil, adError(ad, ErrNoSegments)
+		}
+		s := stitchedAd{id: ad.ID, segments: segs}
+		for _, seg := range segs {
+			s.duration += seg.Duration
+		}
+		s.beacons = Beacons(ad.InLine, linear, s.duration)
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// adError wraps err, which already names its package, with the id of ad.
+func adError(ad *vast2.Ad, err error) error {
+	return fmt.Errorf("%w (Ad %q)", err, ad.ID)
+}
+
+func firstLinear(ad *vast2.Ad) *vast2.Linear {
+	if ad.Kind() != vast2.AdInLine {
+		return nil
+	}
+	for _, c := range ad.InLine.Creatives.Creative {
+		if c.Linear != nil {
+			return c.Linear
+		}
+	}
+	return nil
+}
+
+// Beacons returns the impressions of inLine and the progress tracking events
+// of linear, for an ad lasting duration, ordered by offset and then by the
+// order they are fired in. Events which do not depend on the playback
+// position, such as pause, are left out. Beacons fired by the server should
+// be sent with Resolver.Fire and a context from vast2.WithClientInfo, which
+// forwards the viewer information.
+func Beacons(inLine *vast2.InLine, linear *vast2.Linear, duration time.Duration) []Beacon {
+	var beacons []Beacon
+	for _, e := range beaconEvents {
+		offset := duration * e.num / e.den
+		if e.event == "impression" {
+			for _, imp := range inLine.Impression {
+				if url := strings.TrimSpace(imp.Data); url != "" {
+					beacons = append(beacons, Beacon{Event: e.event, URL: url, Offset: offset})
+				}
+			}
+			continue
+		}
+		if linear.TrackingEvents == nil {
+			continue
+		}
+		for _, tr := range linear.TrackingEvents.Tracking {
+			if url := strings.TrimSpace(tr.Data); tr.Event == e.event && url != "" {
+				beacons = append(beacons, Beacon{Event: e.event, URL: url, Offset: offset})
+			}
+		}
+	}
+	return beacons
+}
+
+// segmentBeacons splits beacons by the segment of segs playing at their
+// offset, which becomes relative to the start of the segment. Beacons at the
+// very end of the ad go with the last segment.
+func segmentBeacons(segs []Segment, beacons []Beacon) [][]Beacon {
+	res := make([][]Beacon, len(segs))
+	var start time.Duration
+	b := 0
+	for i, seg := range segs {
+		end := start + seg.Duration
+		for ; b < len(beacons); b++ {
+			if beacons[b].Offset >= end && i < len(segs)-1 {
+				break
+			}
+			beacon := beacons[b]
+			beacon.Offset -= start
+			res[i] = append(res[i], beacon)
+		}
+		start = end
+	}
+	return res
+}
+
+// seconds formats d as decimal seconds with millisecond precision.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%d.%03d", d/time.Second, d%time.Second/time.Millisecond)
+}
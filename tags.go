@@ -0,0 +1,99 @@
+package vast2
+
+import (
+	"context"
+	"time"
+)
+
+// TagResult is the outcome of a demand tag fetched by FetchTags or
+// FetchFirstValid.
+type TagResult struct {
+	// Index is the position of the tag in the URLs, its priority.
+	Index int
+	URL   string
+	// Ad is the first Ad of the response resolved to an InLine Ad, nil if
+	// Err is set.
+	Ad *Ad
+	// Hops are the fetch of the tag followed by those of its wrapper
+	// chain.
+	Hops []Hop
+	// Duration is the time taken to resolve the tag.
+	Duration time.Duration
+	// Code is the error code matching Err, as fired by a Waterfall.
+	Code int
+	Err  error
+}
+
+// FetchTags fetches the tags at urls concurrently and resolves the first Ad
+// of each response. ctx bounds the whole operation. The results are ordered
+// as urls, by priority.
+func (r *Resolver) FetchTags(ctx context.Context, urls []string) []TagResult {
+	results, _ := r.fetchTags(ctx, urls, false)
+	return results
+}
+
+// FetchFirstValid fetches the tags at urls concurrently, as FetchTags, and
+// returns the first one to resolve to an InLine Ad. The fetches of the other
+// tags are then cancelled: their Err wraps context.Canceled. The results of
+// every tag are returned in the order of urls. The first result is nil if no
+// tag is valid.
+func (r *Resolver) FetchFirstValid(ctx context.Context, urls []string) (*TagResult, []TagResult) {
+	results, first := r.fetchTags(ctx, urls, true)
+	if first < 0 {
+		return nil, results
+	}
+	return &results[first], results
+}
+
+// fetchTags resolves the tags at urls concurrently and returns the index of
+// the first one to resolve successfully, -1 if none did. With firstValid,
+// the other fetches are cancelled as soon as one succeeds.
+func (r *Resolver) fetchTags(ctx context.Context, urls []string, firstValid bool) ([]TagResult, int) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan TagResult)
+	for i, url := range urls {
+		go func(i int, url string) {
+			done <- r.fetchTag(ctx, i, url)
+		}(i, url)
+	}
+
+	results := make([]TagResult, len(urls))
+	first := -1
+	for range urls {
+		res := <-done
+		results[res.Index] = res
+		if res.Err == nil && first < 0 {
+			first = res.Index
+			if firstValid {
+				cancel()
+			}
+		}
+	}
+	return results, first
+}
+
+func (r *Resolver) fetchTag(ctx context.Context, i int, url string) (res TagResult) {
+	start := time.Now()
+	res = TagResult{Index: i, URL: url}
+	defer func() { res.Duration = time.Since(start) }()
+
+	hop := r.Fetch(ctx, url)
+	res.Hops = []Hop{hop}
+	err := hop.Err
+	if err == nil {
+		if ad := firstAd(hop.VAST); ad == nil {
+			err = ErrNoAd
+		} else {
+			var hops []Hop
+			res.Ad, hops, err = r.ResolveAd(ctx, ad)
+			res.Hops = append(res.Hops, hops...)
+		}
+	}
+	if err != nil {
+		res.Err = err
+		res.Code = wrapperErrorCode(err)
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package vast2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTagsServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	slow := func(d time.Duration, v *VAST) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(d):
+				serveVAST(t, v)(w, r)
+			case <-r.Context().Done():
+			}
+		}
+	}
+	mux.HandleFunc("/inline", serveVAST(t, New(testInLineAd())))
+	mux.HandleFunc("/slow", slow(time.Second, New(testInLineAd())))
+	mux.HandleFunc("/fast", slow(50*time.Millisecond, New(testInLineAd())))
+	mux.HandleFunc("/wrapper", serveVAST(t, New(testWrapperAd("w", server.URL+"/inline"))))
+	mux.HandleFunc("/nofill", serveVAST(t, NoFill()))
+	return server
+}
+
+func TestResolverFetchTags(t *testing.T) {
+	server := testTagsServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	urls := []string{server.URL + "/slow", server.URL + "/wrapper", server.URL + "/nofill", server.URL + "/missing", server.URL + "/inline"}
+	results := (&Resolver{}).FetchTags(ctx, urls)
+	assert.Len(t, results, 5)
+	for i, res := range results {
+		assert.Equal(t, res.Index, i)
+		assert.Equal(t, res.URL, urls[i])
+		assert.True(t, res.Duration > 0)
+	}
+
+	assert.True(t, errors.Is(results[0].Err, context.DeadlineExceeded))
+	assert.Equal(t, results[0].Code, ErrorCodeWrapperTimeout)
+	assert.Nil(t, results[0].Ad)
+
+	assert.Nil(t, results[1].Err)
+	assert.Equal(t, results[1].Code, 0)
+	assert.Len(t, results[1].Hops, 2)
+	assert.Equal(t, results[1].Ad.ID, "w")
+	assert.Equal(t, results[1].Ad.InLine.AdSystem.Data, "partner")
+	assert.Len(t, results[1].Ad.InLine.Impression, 2)
+
+	assert.Equal(t, results[2].Err, ErrNoAd)
+	assert.Equal(t, results[2].Code, ErrorCodeWrapperNoAd)
+
+	assert.Equal(t, results[3].Err, &StatusError{URL: server.URL + "/missing", StatusCode: 404})
+	assert.Equal(t, results[3].Code, ErrorCodeWrapper)
+	assert.Len(t, results[3].Hops, 1)
+
+	assert.Nil(t, results[4].Err)
+	assert.Equal(t, results[4].Ad.ID, "inline")
+
+	assert.Empty(t, (&Resolver{}).FetchTags(ctx, nil))
+}
+
+func TestResolverFetchFirstValid(t *testing.T) {
+	server := testTagsServer(t)
+	defer server.Close()
+
+	start := time.Now()
+	urls := []string{server.URL + "/slow", server.URL + "/missing", server.URL + "/fast"}
+	first, results := (&Resolver{}).FetchFirstValid(context.Background(), urls)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.NotNil(t, first)
+	assert.Equal(t, first.Index, 2)
+	assert.Equal(t, first.Ad.ID, "inline")
+	assert.True(t, first == &results[2])
+	assert.True(t, errors.Is(results[0].Err, context.Canceled))
+	assert.NotNil(t, results[1].Err)
+
+	first, results = (&Resolver{}).FetchFirstValid(context.Background(), []string{server.URL + "/nofill", server.URL + "/missing"})
+	assert.Nil(t, first)
+	assert.Len(t, results, 2)
+}
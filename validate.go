@@ -0,0 +1,203 @@
+package vast2
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// ValidationError is a violation of the VAST 2.0 specification found by
+// Validate at the element with the given path.
+type ValidationError struct {
+	Severity Severity
+	Path     Path
+	Message  string
+}
+
+func (e ValidationError) Error() string {
+	if len(e.Path) == 0 {
+		return "VAST: " + e.Message
+	}
+	return e.Path.String() + ": " + e.Message
+}
+
+var trackingEvents = map[string]bool{
+	"creativeView":     true,
+	"start":            true,
+	"firstQuartile":    true,
+	"midpoint":         true,
+	"thirdQuartile":    true,
+	"complete":         true,
+	"mute":             true,
+	"unmute":           true,
+	"pause":            true,
+	"rewind":           true,
+	"resume":           true,
+	"fullscreen":       true,
+	"expand":           true,
+	"collapse":         true,
+	"acceptInvitation": true,
+	"close":            true,
+}
+
+// Validate checks v against the VAST 2.0 specification and returns every
+// problem found, errors and warnings alike, in document order.
+func Validate(v *VAST) []ValidationError {
+	var errs []ValidationError
+	report := func(sev Severity, p Path, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Severity: sev, Path: p, Message: fmt.Sprintf(format, args...)})
+	}
+	if v == nil {
+		report(SeverityError, nil, "missing document")
+		return errs
+	}
+
+	switch strings.TrimSpace(v.Version) {
+	case "":
+		report(SeverityError, nil, "missing version attribute")
+	case "2.0", "2.0.1":
+	default:
+		report(SeverityWarning, nil, "unexpected version %q", v.Version)
+	}
+
+	Walk(v, Visitor{
+		Ad: func(p Path, ad *Ad) WalkAction {
+			switch ad.Kind() {
+			case AdEmpty:
+				report(SeverityError, p, "missing InLine or Wrapper")
+			case AdAmbiguous:
+				report(SeverityError, p, "both InLine and Wrapper are set")
+			}
+			return WalkContinue
+		},
+		InLine: func(p Path, inLine *InLine) WalkAction {
+			if strings.TrimSpace(inLine.AdSystem.Data) == "" {
+				report(SeverityError, p, "missing AdSystem")
+			}
+			if strings.TrimSpace(inLine.AdTitle) == "" {
+				report(SeverityError, p, "missing AdTitle")
+			}
+			if !hasImpression(inLine.Impression) {
+				report(SeverityError, p, "missing Impression")
+			}
+			if len(inLine.Creatives.Creative) == 0 {
+				report(SeverityError, p, "missing Creative")
+			}
+			return WalkContinue
+		},
+		Wrapper: func(p Path, wrapper *Wrapper) WalkAction {
+			if strings.TrimSpace(wrapper.AdSystem.Data) == "" {
+				report(SeverityError, p, "missing AdSystem")
+			}
+			if strings.TrimSpace(wrapper.VASTAdTagURI) == "" {
+				report(SeverityError, p, "missing VASTAdTagURI")
+			}
+			if !hasImpression(wrapper.Impression) {
+				report(SeverityError, p, "missing Impression")
+			}
+			return WalkContinue
+		},
+		Creative: func(p Path, creative *Creative) WalkAction {
+			switch creative.Kind() {
+			case CreativeAmbiguous:
+				report(SeverityError, p, "more than one of Linear, CompanionAds and NonLinearAds")
+			case CreativeEmpty:
+				if inInLine(p) {
+					report(SeverityError, p, "missing Linear, CompanionAds or NonLinearAds")
+				}
+			}
+			return WalkContinue
+		},
+		Linear: func(p Path, linear *Linear) WalkAction {
+			if !inInLine(p) {
+				return WalkContinue
+			}
+			if strings.TrimSpace(linear.Duration) == "" {
+				report(SeverityError, p, "missing Duration")
+			} else if _, err := ParseDuration(linear.Duration); err != nil {
+				report(SeverityError, p, "invalid Duration %q", strings.TrimSpace(linear.Duration))
+			}
+			if len(linear.MediaFiles.MediaFile) == 0 {
+				report(SeverityError, p, "missing MediaFile")
+			}
+			return WalkContinue
+		},
+		MediaFile: func(p Path, mediaFile *MediaFile) WalkAction {
+			switch strings.TrimSpace(mediaFile.Delivery) {
+			case "progressive", "streaming":
+			case "":
+				report(SeverityError, p, "missing delivery attribute")
+			default:
+				report(SeverityError, p, "invalid delivery attribute %q", mediaFile.Delivery)
+			}
+			if strings.TrimSpace(mediaFile.Type) == "" {
+				report(SeverityError, p, "missing type attribute")
+			}
+			if mediaFile.Width <= 0 || mediaFile.Height <= 0 {
+				report(SeverityError, p, "missing width or height attribute")
+			}
+			if strings.TrimSpace(mediaFile.Data) == "" {
+				report(SeverityError, p, "missing URL")
+			}
+			return WalkContinue
+		},
+		Tracking: func(p Path, tracking *Tracking) WalkAction {
+			if !trackingEvents[strings.TrimSpace(tracking.Event)] {
+				report(SeverityWarning, p, "unknown event %q", tracking.Event)
+			}
+			return WalkContinue
+		},
+		Companion: func(p Path, companion *Companion) WalkAction {
+			if companion.Width <= 0 || companion.Height <= 0 {
+				report(SeverityError, p, "missing width or height attribute")
+			}
+			if companion.StaticResource == nil && companion.IFrameResource == "" && companion.HTMLResource == "" {
+				report(SeverityError, p, "missing StaticResource, IFrameResource or HTMLResource")
+			}
+			return WalkContinue
+		},
+		NonLinear: func(p Path, nonLinear *NonLinear) WalkAction {
+			if nonLinear.Width <= 0 || nonLinear.Height <= 0 {
+				report(SeverityError, p, "missing width or height attribute")
+			}
+			if nonLinear.StaticResource == nil && nonLinear.IFrameResource == "" && nonLinear.HTMLResource == "" {
+				report(SeverityError, p, "missing StaticResource, IFrameResource or HTMLResource")
+			}
+			return WalkContinue
+		},
+		URL: func(p Path, kind URLKind, s *string) {
+			u, err := url.Parse(strings.TrimSpace(*s))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				report(SeverityWarning, p, "%s is not an absolute http(s) URL: %q", kind, strings.TrimSpace(*s))
+			}
+		},
+	})
+	return errs
+}
+
+func hasImpression(imps []Impression) bool {
+	for _, imp := range imps {
+		if strings.TrimSpace(imp.Data) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func inInLine(p Path) bool {
+	return len(p) > 1 && p[1].Name == "InLine"
+}
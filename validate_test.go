@@ -0,0 +1,110 @@
+package vast2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testValidVAST() *VAST {
+	return &VAST{
+		Version: "2.0",
+		Ad: []Ad{
+			{
+				ID: "1",
+				InLine: &InLine{
+					AdTitle:    "Title",
+					AdSystem:   AdSystem{Data: "pubnative"},
+					Impression: []Impression{{Data: "http://imp.com"}},
+					Creatives: Creatives{Creative: []Creative{
+						{
+							Linear: &Linear{
+								Duration:       "00:00:30",
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "start", Data: "http://start.com"}}},
+								MediaFiles: MediaFiles{MediaFile: []MediaFile{
+									{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: "http://media.com/a.mp4"},
+								}},
+							},
+						},
+						{
+							CompanionAds: &CompanionAds{Companion: []Companion{{
+								Width:          300,
+								Height:         250,
+								StaticResource: &StaticResource{CreativeType: "image/png", Data: "http://static.com/a.png"},
+							}}},
+						},
+					}},
+				},
+			},
+			{
+				ID: "2",
+				Wrapper: &Wrapper{
+					VASTAdTagURI: "https://tag.com/vast.xml",
+					AdSystem:     AdSystem{Data: "partner"},
+					Impression:   []Impression{{Data: "http://wrapper-imp.com"}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	assert.Nil(t, Validate(testValidVAST()))
+}
+
+func TestValidateNil(t *testing.T) {
+	errs := Validate(nil)
+	assert.Equal(t, len(errs), 1)
+	assert.Equal(t, errs[0].Error(), "VAST: missing document")
+}
+
+func TestValidateErrors(t *testing.T) {
+	vast := testValidVAST()
+	vast.Version = "3.0"
+	inLine := vast.Ad[0].InLine
+	inLine.AdTitle = " "
+	inLine.Impression = nil
+	linear := inLine.Creatives.Creative[0].Linear
+	linear.Duration = "30s"
+	linear.TrackingEvents.Tracking[0].Event = "starts"
+	linear.MediaFiles.MediaFile[0].Delivery = "download"
+	linear.MediaFiles.MediaFile[0].Height = 0
+	inLine.Creatives.Creative[1].Linear = &Linear{}
+	inLine.Creatives.Creative[1].CompanionAds.Companion[0].StaticResource = nil
+	vast.Ad[1].Wrapper.VASTAdTagURI = "tag.com/vast.xml"
+	vast.Ad = append(vast.Ad, Ad{ID: "3"})
+
+	var msgs []string
+	for _, err := range Validate(vast) {
+		msgs = append(msgs, err.Severity.String()+" "+err.Error())
+	}
+	assert.Equal(t, msgs, []string{
+		`warning VAST: unexpected version "3.0"`,
+		`error Ad[0]/InLine: missing AdTitle`,
+		`error Ad[0]/InLine: missing Impression`,
+		`error Ad[0]/InLine/Creatives/Creative[0]/Linear: invalid Duration "30s"`,
+		`warning Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]: unknown event "starts"`,
+		`error Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]: invalid delivery attribute "download"`,
+		`error Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]: missing width or height attribute`,
+		`error Ad[0]/InLine/Creatives/Creative[1]: more than one of Linear, CompanionAds and NonLinearAds`,
+		`error Ad[0]/InLine/Creatives/Creative[1]/Linear: missing Duration`,
+		`error Ad[0]/InLine/Creatives/Creative[1]/Linear: missing MediaFile`,
+		`error Ad[0]/InLine/Creatives/Creative[1]/CompanionAds/Companion[0]: missing StaticResource, IFrameResource or HTMLResource`,
+		`warning Ad[1]/Wrapper/VASTAdTagURI: VASTAdTagURI is not an absolute http(s) URL: "tag.com/vast.xml"`,
+		`error Ad[2]: missing InLine or Wrapper`,
+	})
+}
+
+func TestValidateMissingVersion(t *testing.T) {
+	errs := Validate(&VAST{})
+	assert.Equal(t, errs, []ValidationError{{Severity: SeverityError, Message: "missing version attribute"}})
+}
+
+func TestValidateWrapperCreatives(t *testing.T) {
+	vast := testValidVAST()
+	vast.Ad[1].Wrapper.Creatives.Creative = []Creative{
+		{},
+		{Linear: &Linear{TrackingEvents: &TrackingEvents{Tracking: []Tracking{{Event: "start", Data: "http://start.com"}}}}},
+	}
+	assert.Nil(t, Validate(vast))
+}
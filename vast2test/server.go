@@ -0,0 +1,282 @@
+// Package vast2test provides a mock VAST ad server for tests.
+package vast2test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+)
+
+const beaconPrefix = "/beacon/"
+
+// Response describes how the server answers requests for a path.
+type Response struct {
+	// VAST is encoded as the response body unless Body is set.
+	VAST *vast2.VAST
+	// Body is written as is, e.g. to serve malformed XML.
+	Body []byte
+	// Status is the HTTP status, 200 if 0.
+	Status int
+	// Delay is waited before answering.
+	Delay time.Duration
+}
+
+// Hit is a request received by the beacon collector.
+type Hit struct {
+	Name   string
+	URL    string
+	Header http.Header
+	Time   time.Time
+}
+
+// Server is a mock ad server with a beacon collector. Its responses can be
+// changed while it is running.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]Response
+	requests  map[string]int
+	hits      []Hit
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished.
+func NewServer() *Server {
+	s := &Server{
+		responses: map[string]Response{},
+		requests:  map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, beaconPrefix) {
+		s.mu.Lock()
+		s.hits = append(s.hits, Hit{
+			Name:   strings.TrimPrefix(r.URL.Path, beaconPrefix),
+			URL:    r.URL.String(),
+			Header: r.Header.Clone(),
+			Time:   time.Now(),
+		})
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	s.mu.Lock()
+	resp, ok := s.responses[r.URL.Path]
+	s.requests[r.URL.Path]++
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	body := resp.Body
+	if body == nil && resp.VAST != nil {
+		data, err := xml.Marshal(resp.VAST)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = append([]byte(xml.Header), data...)
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Handle sets the response for path and returns its URL.
+func (s *Server) Handle(path string, resp Response) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.mu.Lock()
+	s.responses[path] = resp
+	s.mu.Unlock()
+	return s.URL + path
+}
+
+// VAST serves v at path and returns its URL.
+func (s *Server) VAST(path string, v *vast2.VAST) string {
+	return s.Handle(path, Response{VAST: v})
+}
+
+// Error answers requests for path with the given HTTP status.
+func (s *Server) Error(path string, status int) string {
+	return s.Handle(path, Response{Status: status, Body: []byte(http.StatusText(status))})
+}
+
+// Malformed serves a truncated VAST document at path.
+func (s *Server) Malformed(path string) string {
+	return s.Handle(path, Response{Body: []byte(`<VAST version="2.0"><Ad id="1"><InLine>`)})
+}
+
+// NoFill serves a VAST document without Ads at path.
+func (s *Server) NoFill(path string) string {
+	return s.VAST(path, vast2.NoFill())
+}
+
+// Delay waits d before answering requests for path, which must have been
+// set up already.
+func (s *Server) Delay(path string, d time.Duration) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.mu.Lock()
+	if resp, ok := s.responses[path]; ok {
+		resp.Delay = d
+		s.responses[path] = resp
+	}
+	s.mu.Unlock()
+}
+
+// Requests returns the number of requests received for path.
+func (s *Server) Requests(path string) int {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// BeaconURL returns the URL of the beacon collector for name.
+func (s *Server) BeaconURL(name string) string {
+	return s.URL + beaconPrefix + name
+}
+
+// Hits returns the beacon hits received so far, oldest first.
+func (s *Server) Hits() []Hit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Hit(nil), s.hits...)
+}
+
+// HitNames returns the names of the beacons hit so far, oldest first.
+func (s *Server) HitNames() []string {
+	var names []string
+	for _, hit := range s.Hits() {
+		names = append(names, hit.Name)
+	}
+	return names
+}
+
+// Count returns the number of hits of the beacon name.
+func (s *Server) Count(name string) int {
+	n := 0
+	for _, hit := range s.Hits() {
+		if hit.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset forgets the recorded hits and request counts.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.hits = nil
+	s.requests = map[string]int{}
+	s.mu.Unlock()
+}
+
+// InLine returns a VAST document with a single valid InLine Ad whose
+// impression, error, click and tracking URLs point to the beacon collector
+// as name/impression, name/error, name/click, name/start and so on.
+func (s *Server) InLine(name string) *vast2.VAST {
+	var tracking []vast2.Tracking
+	for _, event := range []string{"start", "firstQuartile", "midpoint", "thirdQuartile", "complete"} {
+		tracking = append(tracking, vast2.Tracking{Event: event, Data: s.BeaconURL(name + "/" + event)})
+	}
+	return &vast2.VAST{
+		Version: "2.0",
+		Ad: []vast2.Ad{{
+			ID: name,
+			InLine: &vast2.InLine{
+				AdTitle:    name,
+				AdSystem:   vast2.AdSystem{Data: "vast2test"},
+				Error:      []string{s.BeaconURL(name + "/error")},
+				Impression: []vast2.Impression{{Data: s.BeaconURL(name + "/impression")}},
+				Creatives: vast2.Creatives{Creative: []vast2.Creative{{
+					Linear: &vast2.Linear{
+						Duration:       "00:00:15",
+						TrackingEvents: &vast2.TrackingEvents{Tracking: tracking},
+						VideoClicks: &vast2.VideoClicks{
+							ClickThrough:  "http://advertiser.example/",
+							ClickTracking: []vast2.ClickTracking{{Data: s.BeaconURL(name + "/click")}},
+						},
+						MediaFiles: vast2.MediaFiles{MediaFile: []vast2.MediaFile{{
+							Delivery: "progressive",
+							Type:     "video/mp4",
+							Width:    640,
+							Height:   360,
+							Data:     s.URL + "/media/" + name + ".mp4",
+						}}},
+					},
+				}}},
+			},
+		}},
+	}
+}
+
+// Wrapper returns a VAST document with a single Wrapper Ad pointing to
+// tagURI whose impression, error and start URLs point to the beacon
+// collector as name/impression, name/error and name/start.
+func (s *Server) Wrapper(name, tagURI string) *vast2.VAST {
+	return &vast2.VAST{
+		Version: "2.0",
+		Ad: []vast2.Ad{{
+			ID: name,
+			Wrapper: &vast2.Wrapper{
+				VASTAdTagURI: tagURI,
+				AdSystem:     vast2.AdSystem{Data: "vast2test"},
+				Error:        []string{s.BeaconURL(name + "/error")},
+				Impression:   []vast2.Impression{{Data: s.BeaconURL(name + "/impression")}},
+				Creatives: vast2.Creatives{Creative: []vast2.Creative{{
+					Linear: &vast2.Linear{
+						TrackingEvents: &vast2.TrackingEvents{Tracking: []vast2.Tracking{
+							{Event: "start", Data: s.BeaconURL(name + "/start")},
+						}},
+					},
+				}}},
+			},
+		}},
+	}
+}
+
+// WrapperChain serves depth wrappers at path, path/wrapper1, ... leading to
+// final at path/inline and returns the URL of the first wrapper. The
+// wrappers are named wrapper0, wrapper1, ... for their beacons.
+func (s *Server) WrapperChain(path string, depth int, final *vast2.VAST) string {
+	path = "/" + strings.Trim(path, "/")
+	next := s.VAST(path+"/inline", final)
+	for i := depth - 1; i >= 0; i-- {
+		p := path
+		if i > 0 {
+			p = fmt.Sprintf("%s/wrapper%d", path, i)
+		}
+		next = s.VAST(p, s.Wrapper(fmt.Sprintf("wrapper%d", i), next))
+	}
+	return next
+}
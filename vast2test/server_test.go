@@ -0,0 +1,123 @@
+package vast2test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	vast2 "github.com/pubnative/vast2-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestServerVAST(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	url := s.VAST("tag", s.InLine("ad"))
+	assert.Equal(t, url, s.URL+"/tag")
+
+	status, body := get(t, url)
+	assert.Equal(t, status, 200)
+
+	var v vast2.VAST
+	assert.Nil(t, xml.Unmarshal([]byte(body), &v))
+	assert.Equal(t, &v, s.InLine("ad"))
+	assert.Nil(t, vast2.Validate(&v))
+	assert.Equal(t, s.Requests("tag"), 1)
+}
+
+func TestServerErrors(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	status, _ := get(t, s.Error("error", http.StatusServiceUnavailable))
+	assert.Equal(t, status, http.StatusServiceUnavailable)
+
+	status, _ = get(t, s.URL+"/unknown")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	_, body := get(t, s.NoFill("nofill"))
+	assert.Equal(t, body, xml.Header+`<VAST version="2.0"></VAST>`)
+
+	var v vast2.VAST
+	_, body = get(t, s.Malformed("malformed"))
+	assert.NotNil(t, xml.Unmarshal([]byte(body), &v))
+}
+
+func TestServerDelay(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	url := s.Handle("slow", Response{VAST: s.InLine("ad"), Delay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	hop := (&vast2.Resolver{}).Fetch(ctx, url)
+	assert.True(t, errors.Is(hop.Err, context.DeadlineExceeded))
+
+	s.Delay("slow", 10*time.Millisecond)
+	hop = (&vast2.Resolver{}).Fetch(context.Background(), url)
+	assert.Nil(t, hop.Err)
+	assert.True(t, hop.Duration >= 10*time.Millisecond)
+}
+
+func TestServerWrapperChain(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	url := s.WrapperChain("chain", 3, s.InLine("ad"))
+	assert.Equal(t, url, s.URL+"/chain")
+
+	ad := vast2.Ad{Wrapper: &vast2.Wrapper{VASTAdTagURI: url}}
+	resolved, hops, err := (&vast2.Resolver{}).ResolveAd(context.Background(), &ad)
+	assert.Nil(t, err)
+	assert.Equal(t, len(hops), 4)
+	assert.Equal(t, hops[1].URL, s.URL+"/chain/wrapper1")
+	assert.Equal(t, hops[3].URL, s.URL+"/chain/inline")
+	assert.Equal(t, resolved.InLine.Impression, []vast2.Impression{
+		{Data: s.BeaconURL("ad/impression")},
+		{Data: s.BeaconURL("wrapper2/impression")},
+		{Data: s.BeaconURL("wrapper1/impression")},
+		{Data: s.BeaconURL("wrapper0/impression")},
+	})
+}
+
+func TestServerBeacons(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodGet, s.BeaconURL("ad/start")+"?cb=1", nil)
+	assert.Nil(t, err)
+	req.Header.Set("X-Device-User-Agent", "player")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusNoContent)
+
+	get(t, s.BeaconURL("ad/impression"))
+	get(t, s.BeaconURL("ad/start"))
+
+	hits := s.Hits()
+	assert.Equal(t, len(hits), 3)
+	assert.Equal(t, hits[0].Name, "ad/start")
+	assert.Equal(t, hits[0].URL, "/beacon/ad/start?cb=1")
+	assert.Equal(t, hits[0].Header.Get("X-Device-User-Agent"), "player")
+	assert.Equal(t, s.HitNames(), []string{"ad/start", "ad/impression", "ad/start"})
+	assert.Equal(t, s.Count("ad/start"), 2)
+	assert.Equal(t, s.Count("ad/complete"), 0)
+
+	s.Reset()
+	assert.Nil(t, s.Hits())
+}
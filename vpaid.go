@@ -0,0 +1,158 @@
+package vast2
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// APIFrameworkVPAID is the apiFramework of VPAID creatives.
+const APIFrameworkVPAID = "VPAID"
+
+// vpaidTypes are the MediaFile types of VPAID creatives.
+var vpaidTypes = map[string]bool{
+	"application/javascript":        true,
+	"application/x-javascript":      true,
+	"text/javascript":               true,
+	"application/x-shockwave-flash": true,
+}
+
+func isVPAIDFramework(apiFramework string) bool {
+	return strings.EqualFold(strings.TrimSpace(apiFramework), APIFrameworkVPAID)
+}
+
+// IsVPAID reports whether m is a VPAID unit rather than a playable video
+// file, either by its apiFramework or by a JavaScript or Flash type.
+func (m MediaFile) IsVPAID() bool {
+	return isVPAIDFramework(m.ApiFramework) || vpaidTypes[strings.ToLower(strings.TrimSpace(m.Type))]
+}
+
+// IsVPAID reports whether n is run by a VPAID unit.
+func (n NonLinear) IsVPAID() bool {
+	return isVPAIDFramework(n.ApiFramework)
+}
+
+// HasVPAID reports whether any of the media files of l is a VPAID unit.
+func (l *Linear) HasVPAID() bool {
+	for _, m := range l.MediaFiles.MediaFile {
+		if m.IsVPAID() {
+			return true
+		}
+	}
+	return false
+}
+
+// VPAIDMediaFiles returns the media files of l which are VPAID units.
+func (l *Linear) VPAIDMediaFiles() []MediaFile {
+	return filterMediaFiles(l.MediaFiles.MediaFile, true)
+}
+
+// PlayableMediaFiles returns the media files of l which are not VPAID units.
+func (l *Linear) PlayableMediaFiles() []MediaFile {
+	return filterMediaFiles(l.MediaFiles.MediaFile, false)
+}
+
+func filterMediaFiles(files []MediaFile, vpaid bool) []MediaFile {
+	var res []MediaFile
+	for _, m := range files {
+		if m.IsVPAID() == vpaid {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// SetAdParametersJSON sets the AdParameters of l to the JSON encoding of v.
+func (l *Linear) SetAdParametersJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	l.AdParameters = string(data)
+	return nil
+}
+
+// DecodeAdParametersJSON unmarshals the JSON AdParameters of l into v.
+func (l *Linear) DecodeAdParametersJSON(v interface{}) error {
+	return json.Unmarshal([]byte(strings.TrimSpace(l.AdParameters)), v)
+}
+
+// SetAdParametersXML sets the AdParameters of l to the XML encoding of v.
+// VAST 2.0 has no xmlEncoded attribute: the document is stored as the text
+// of AdParameters, which is escaped or wrapped in CDATA on encoding.
+func (l *Linear) SetAdParametersXML(v interface{}) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	l.AdParameters = string(data)
+	return nil
+}
+
+// DecodeAdParametersXML unmarshals the XML AdParameters of l into v.
+func (l *Linear) DecodeAdParametersXML(v interface{}) error {
+	return xml.Unmarshal([]byte(l.AdParameters), v)
+}
+
+// StripVPAID removes VPAID from v for players which cannot run it:
+//   - VPAID media files and the AdParameters of their Linear;
+//   - VPAID NonLinears;
+//   - InLine Creatives left without a playable Linear or NonLinear;
+//   - InLine Ads left without any Creative.
+func StripVPAID(v *VAST) {
+	Walk(v, Visitor{
+		Ad: func(p Path, ad *Ad) WalkAction {
+			if ad.InLine == nil || len(ad.InLine.Creatives.Creative) == 0 {
+				return WalkContinue
+			}
+			for _, c := range ad.InLine.Creatives.Creative {
+				if !vpaidOnly(&c) {
+					return WalkContinue
+				}
+			}
+			return WalkRemove
+		},
+		Creative: func(p Path, creative *Creative) WalkAction {
+			if inInLine(p) && vpaidOnly(creative) {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+		Linear: func(p Path, linear *Linear) WalkAction {
+			if linear.HasVPAID() {
+				linear.AdParameters = ""
+			}
+			return WalkContinue
+		},
+		MediaFile: func(p Path, m *MediaFile) WalkAction {
+			if m.IsVPAID() {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+		NonLinear: func(p Path, n *NonLinear) WalkAction {
+			if n.IsVPAID() {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+	})
+}
+
+// vpaidOnly reports whether the Linear or NonLinearAds of c hold nothing
+// but VPAID units.
+func vpaidOnly(c *Creative) bool {
+	switch {
+	case c.Linear != nil:
+		files := c.Linear.MediaFiles.MediaFile
+		return len(files) > 0 && len(c.Linear.PlayableMediaFiles()) == 0
+	case c.NonLinearAds != nil:
+		for _, n := range c.NonLinearAds.NonLinear {
+			if !n.IsVPAID() {
+				return false
+			}
+		}
+		return len(c.NonLinearAds.NonLinear) > 0
+	}
+	return false
+}
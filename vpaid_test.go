@@ -0,0 +1,113 @@
+package vast2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testVPAIDLinear() *Linear {
+	return &Linear{
+		Duration:     "00:00:30",
+		AdParameters: `{"id":1}`,
+		MediaFiles: MediaFiles{MediaFile: []MediaFile{
+			{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, Data: "http://media.com/a.mp4"},
+			{Delivery: "progressive", Type: "application/javascript", ApiFramework: "VPAID", Width: 640, Height: 360, Data: "http://media.com/vpaid.js"},
+			{Delivery: "progressive", Type: "application/x-shockwave-flash", Width: 640, Height: 360, Data: "http://media.com/vpaid.swf"},
+		}},
+	}
+}
+
+func TestMediaFileIsVPAID(t *testing.T) {
+	assert.False(t, MediaFile{Type: "video/mp4"}.IsVPAID())
+	assert.True(t, MediaFile{Type: "video/mp4", ApiFramework: "vpaid"}.IsVPAID())
+	assert.True(t, MediaFile{Type: " Application/JavaScript "}.IsVPAID())
+	assert.True(t, MediaFile{Type: "application/x-shockwave-flash"}.IsVPAID())
+	assert.False(t, NonLinear{ApiFramework: "js"}.IsVPAID())
+	assert.True(t, NonLinear{ApiFramework: "VPAID"}.IsVPAID())
+}
+
+func TestLinearMediaFiles(t *testing.T) {
+	linear := testVPAIDLinear()
+	assert.True(t, linear.HasVPAID())
+	files := linear.MediaFiles.MediaFile
+	assert.Equal(t, linear.PlayableMediaFiles(), files[:1])
+	assert.Equal(t, linear.VPAIDMediaFiles(), files[1:])
+
+	linear.MediaFiles.MediaFile = files[:1]
+	assert.False(t, linear.HasVPAID())
+	assert.Nil(t, linear.VPAIDMediaFiles())
+}
+
+func TestAdParametersJSON(t *testing.T) {
+	type params struct {
+		ID   int    `json:"id"`
+		Skin string `json:"skin,omitempty"`
+	}
+	linear := &Linear{}
+	assert.Nil(t, linear.SetAdParametersJSON(params{ID: 1, Skin: "dark"}))
+	assert.Equal(t, linear.AdParameters, `{"id":1,"skin":"dark"}`)
+
+	linear.AdParameters = "\n  " + linear.AdParameters + "\n"
+	var res params
+	assert.Nil(t, linear.DecodeAdParametersJSON(&res))
+	assert.Equal(t, res, params{ID: 1, Skin: "dark"})
+
+	linear.AdParameters = "<id>1</id>"
+	assert.NotNil(t, linear.DecodeAdParametersJSON(&res))
+	assert.NotNil(t, linear.SetAdParametersJSON(make(chan int)))
+}
+
+func TestAdParametersXML(t *testing.T) {
+	type params struct {
+		XMLName struct{} `xml:"Params"`
+		ID      int      `xml:"id,attr"`
+		Skin    string   `xml:"Skin"`
+	}
+	linear := &Linear{}
+	assert.Nil(t, linear.SetAdParametersXML(params{ID: 1, Skin: "dark"}))
+	assert.Equal(t, linear.AdParameters, `<Params id="1"><Skin>dark</Skin></Params>`)
+
+	data, err := Format(&VAST{Ad: []Ad{NewInLineAd("1", &InLine{Creatives: Creatives{Creative: []Creative{NewLinearCreative(linear)}}})}}, FormatOptions{CDATA: true})
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `<AdParameters><![CDATA[<Params id="1"><Skin>dark</Skin></Params>]]></AdParameters>`)
+
+	var res params
+	assert.Nil(t, linear.DecodeAdParametersXML(&res))
+	assert.Equal(t, res, params{ID: 1, Skin: "dark"})
+}
+
+func TestStripVPAID(t *testing.T) {
+	vpaidOnly := &Linear{MediaFiles: MediaFiles{MediaFile: testVPAIDLinear().MediaFiles.MediaFile[1:]}}
+	vast := &VAST{Version: "2.0", Ad: []Ad{
+		NewInLineAd("mixed", &InLine{Creatives: Creatives{Creative: []Creative{
+			NewLinearCreative(testVPAIDLinear()),
+			NewLinearCreative(vpaidOnly.Clone()),
+			NewNonLinearAdsCreative(&NonLinearAds{NonLinear: []NonLinear{
+				{ID: "vpaid", ApiFramework: "VPAID"}, {ID: "static"},
+			}}),
+			NewNonLinearAdsCreative(&NonLinearAds{NonLinear: []NonLinear{{ApiFramework: "VPAID"}}}),
+			NewCompanionAdsCreative(&CompanionAds{}),
+		}}}),
+		NewInLineAd("vpaid", &InLine{Creatives: Creatives{Creative: []Creative{
+			NewLinearCreative(vpaidOnly.Clone()),
+		}}}),
+		NewWrapperAd("wrapper", &Wrapper{Creatives: Creatives{Creative: []Creative{
+			NewLinearCreative(&Linear{}),
+		}}}),
+	}}
+	StripVPAID(vast)
+
+	assert.Equal(t, len(vast.Ad), 2)
+	assert.Equal(t, vast.Ad[0].ID, "mixed")
+	assert.Equal(t, vast.Ad[1].ID, "wrapper")
+	assert.Equal(t, len(vast.Ad[1].Wrapper.Creatives.Creative), 1)
+
+	creatives := vast.Ad[0].InLine.Creatives.Creative
+	assert.Equal(t, len(creatives), 3)
+	linear := creatives[0].Linear
+	assert.Equal(t, linear.AdParameters, "")
+	assert.Equal(t, linear.MediaFiles.MediaFile, testVPAIDLinear().MediaFiles.MediaFile[:1])
+	assert.Equal(t, creatives[1].NonLinearAds.NonLinear, []NonLinear{{ID: "static"}})
+	assert.NotNil(t, creatives[2].CompanionAds)
+}
@@ -0,0 +1,343 @@
+package vast2
+
+import (
+	"strconv"
+	"strings"
+)
+
+type WalkAction int
+
+const (
+	// WalkContinue visits the children of the node.
+	WalkContinue WalkAction = iota
+	// WalkSkip does not visit the children of the node.
+	WalkSkip
+	// WalkRemove removes the node from its parent.
+	WalkRemove
+	// WalkStop ends the walk.
+	WalkStop
+)
+
+type PathElem struct {
+	Name  string
+	Index int
+}
+
+// Path is the location of a node in the document, e.g.
+// Ad[0]/InLine/Creatives/Creative[1]/Linear. Indexes refer to positions in
+// the document before any node was removed.
+type Path []PathElem
+
+func (p Path) String() string {
+	var b strings.Builder
+	for i, e := range p {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		b.WriteString(e.Name)
+		if e.Index >= 0 {
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(e.Index))
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
+
+func (p Path) child(name string) Path {
+	return p.childAt(name, -1)
+}
+
+func (p Path) childAt(name string, index int) Path {
+	c := make(Path, len(p), len(p)+1)
+	copy(c, p)
+	return append(c, PathElem{Name: name, Index: index})
+}
+
+// Visitor holds the callbacks called by Walk. Nil callbacks are skipped.
+// The nodes are passed by pointer and may be modified in place.
+type Visitor struct {
+	Ad           func(p Path, ad *Ad) WalkAction
+	InLine       func(p Path, inLine *InLine) WalkAction
+	Wrapper      func(p Path, wrapper *Wrapper) WalkAction
+	Impression   func(p Path, imp *Impression) WalkAction
+	Creative     func(p Path, creative *Creative) WalkAction
+	Linear       func(p Path, linear *Linear) WalkAction
+	Tracking     func(p Path, tracking *Tracking) WalkAction
+	VideoClicks  func(p Path, clicks *VideoClicks) WalkAction
+	MediaFile    func(p Path, mediaFile *MediaFile) WalkAction
+	CompanionAds func(p Path, companionAds *CompanionAds) WalkAction
+	Companion    func(p Path, companion *Companion) WalkAction
+	NonLinearAds func(p Path, nonLinearAds *NonLinearAds) WalkAction
+	NonLinear    func(p Path, nonLinear *NonLinear) WalkAction
+	Extension    func(p Path, ext *Extension) WalkAction
+	// CreativeExtension is called for the CreativeExtensions of Linear,
+	// Companion and NonLinear nodes.
+	CreativeExtension func(p Path, ext *CreativeExtension) WalkAction
+
+	// URL is called for every non-empty URL after the callback of the node
+	// holding it. Setting *url to "" clears it.
+	URL func(p Path, kind URLKind, url *string)
+}
+
+// Walk traverses v depth-first in document order and calls the matching
+// callbacks of vis for every node.
+func Walk(v *VAST, vis Visitor) {
+	if v == nil {
+		return
+	}
+	w := walker{vis: &vis}
+	v.Ad = filter(v.Ad, func(i int, ad *Ad) bool {
+		return w.ad(Path{{Name: "Ad", Index: i}}, ad)
+	})
+}
+
+type walker struct {
+	vis     *Visitor
+	stopped bool
+}
+
+// filter calls fn for every element of s still to be visited and drops the
+// elements for which fn returns false.
+func filter[T any](s []T, fn func(i int, e *T) bool) []T {
+	j := 0
+	for i := range s {
+		if fn(i, &s[i]) {
+			if i != j {
+				s[j] = s[i]
+			}
+			j++
+		}
+	}
+	if j == len(s) {
+		return s
+	}
+	var zero T
+	for i := j; i < len(s); i++ {
+		s[i] = zero
+	}
+	return s[:j]
+}
+
+// visit calls fn if it is set and reports whether the node is kept and
+// whether its children should be visited.
+func visit[T any](w *walker, fn func(Path, *T) WalkAction, p Path, node *T) (keep, descend bool) {
+	if w.stopped {
+		return true, false
+	}
+	if fn == nil {
+		return true, true
+	}
+	switch fn(p, node) {
+	case WalkSkip:
+		return true, false
+	case WalkRemove:
+		return false, false
+	case WalkStop:
+		w.stopped = true
+		return true, false
+	}
+	return true, true
+}
+
+func (w *walker) url(p Path, kind URLKind, url *string) {
+	if w.stopped || w.vis.URL == nil || *url == "" {
+		return
+	}
+	w.vis.URL(p, kind, url)
+}
+
+func (w *walker) ad(p Path, ad *Ad) bool {
+	keep, descend := visit(w, w.vis.Ad, p, ad)
+	if !descend {
+		return keep
+	}
+	if ad.InLine != nil && !w.inLine(p.child("InLine"), ad.InLine) {
+		ad.InLine = nil
+	}
+	if ad.Wrapper != nil && !w.wrapper(p.child("Wrapper"), ad.Wrapper) {
+		ad.Wrapper = nil
+	}
+	return true
+}
+
+func (w *walker) inLine(p Path, inLine *InLine) bool {
+	keep, descend := visit(w, w.vis.InLine, p, inLine)
+	if !descend {
+		return keep
+	}
+	w.url(p.child("Survey"), URLSurvey, &inLine.Survey)
+	w.errors(p, inLine.Error)
+	inLine.Impression = w.impressions(p, inLine.Impression)
+	w.creatives(p.child("Creatives"), &inLine.Creatives)
+	w.extensions(p.child("Extensions"), inLine.Extensions)
+	return true
+}
+
+func (w *walker) errors(p Path, urls []string) {
+	for i := range urls {
+		w.url(p.childAt("Error", i), URLError, &urls[i])
+	}
+}
+
+func (w *walker) wrapper(p Path, wrapper *Wrapper) bool {
+	keep, descend := visit(w, w.vis.Wrapper, p, wrapper)
+	if !descend {
+		return keep
+	}
+	w.url(p.child("VASTAdTagURI"), URLVASTAdTagURI, &wrapper.VASTAdTagURI)
+	w.errors(p, wrapper.Error)
+	wrapper.Impression = w.impressions(p, wrapper.Impression)
+	w.creatives(p.child("Creatives"), &wrapper.Creatives)
+	w.extensions(p.child("Extensions"), wrapper.Extensions)
+	return true
+}
+
+func (w *walker) impressions(p Path, imps []Impression) []Impression {
+	return filter(imps, func(i int, imp *Impression) bool {
+		ip := p.childAt("Impression", i)
+		keep, descend := visit(w, w.vis.Impression, ip, imp)
+		if descend {
+			w.url(ip, URLImpression, &imp.Data)
+		}
+		return keep
+	})
+}
+
+func (w *walker) trackingEvents(p Path, events *TrackingEvents) {
+	if events == nil {
+		return
+	}
+	events.Tracking = filter(events.Tracking, func(i int, tracking *Tracking) bool {
+		tp := p.childAt("Tracking", i)
+		keep, descend := visit(w, w.vis.Tracking, tp, tracking)
+		if descend {
+			w.url(tp, URLTracking, &tracking.Data)
+		}
+		return keep
+	})
+}
+
+func (w *walker) creatives(p Path, creatives *Creatives) {
+	creatives.Creative = filter(creatives.Creative, func(i int, creative *Creative) bool {
+		return w.creative(p.childAt("Creative", i), creative)
+	})
+}
+
+func (w *walker) creative(p Path, creative *Creative) bool {
+	keep, descend := visit(w, w.vis.Creative, p, creative)
+	if !descend {
+		return keep
+	}
+	if creative.Linear != nil && !w.linear(p.child("Linear"), creative.Linear) {
+		creative.Linear = nil
+	}
+	if creative.CompanionAds != nil && !w.companionAds(p.child("CompanionAds"), creative.CompanionAds) {
+		creative.CompanionAds = nil
+	}
+	if creative.NonLinearAds != nil && !w.nonLinearAds(p.child("NonLinearAds"), creative.NonLinearAds) {
+		creative.NonLinearAds = nil
+	}
+	return true
+}
+
+func (w *walker) linear(p Path, linear *Linear) bool {
+	keep, descend := visit(w, w.vis.Linear, p, linear)
+	if !descend {
+		return keep
+	}
+	w.trackingEvents(p.child("TrackingEvents"), linear.TrackingEvents)
+	if linear.VideoClicks != nil && !w.videoClicks(p.child("VideoClicks"), linear.VideoClicks) {
+		linear.VideoClicks = nil
+	}
+	mp := p.child("MediaFiles")
+	linear.MediaFiles.MediaFile = filter(linear.MediaFiles.MediaFile, func(i int, mediaFile *MediaFile) bool {
+		fp := mp.childAt("MediaFile", i)
+		keep, descend := visit(w, w.vis.MediaFile, fp, mediaFile)
+		if descend {
+			w.url(fp, URLMediaFile, &mediaFile.Data)
+		}
+		return keep
+	})
+	w.creativeExtensions(p.child("CreativeExtensions"), linear.CreativeExtensions)
+	return true
+}
+
+func (w *walker) videoClicks(p Path, clicks *VideoClicks) bool {
+	keep, descend := visit(w, w.vis.VideoClicks, p, clicks)
+	if !descend {
+		return keep
+	}
+	w.url(p.child("ClickThrough"), URLClickThrough, &clicks.ClickThrough)
+	for i := range clicks.ClickTracking {
+		w.url(p.childAt("ClickTracking", i), URLClickTracking, &clicks.ClickTracking[i].Data)
+	}
+	for i := range clicks.CustomClick {
+		w.url(p.childAt("CustomClick", i), URLCustomClick, &clicks.CustomClick[i].Data)
+	}
+	return true
+}
+
+func (w *walker) companionAds(p Path, companionAds *CompanionAds) bool {
+	keep, descend := visit(w, w.vis.CompanionAds, p, companionAds)
+	if !descend {
+		return keep
+	}
+	companionAds.Companion = filter(companionAds.Companion, func(i int, companion *Companion) bool {
+		cp := p.childAt("Companion", i)
+		keep, descend := visit(w, w.vis.Companion, cp, companion)
+		if descend {
+			w.url(cp.child("IFrameResource"), URLIFrameResource, &companion.IFrameResource)
+			w.url(cp.child("CompanionClickThrough"), URLCompanionClickThrough, &companion.CompanionClickThrough)
+			if companion.StaticResource != nil {
+				w.url(cp.child("StaticResource"), URLStaticResource, &companion.StaticResource.Data)
+			}
+			w.trackingEvents(cp.child("TrackingEvents"), companion.TrackingEvents)
+			w.creativeExtensions(cp.child("CreativeExtensions"), companion.CreativeExtensions)
+		}
+		return keep
+	})
+	return true
+}
+
+func (w *walker) nonLinearAds(p Path, nonLinearAds *NonLinearAds) bool {
+	keep, descend := visit(w, w.vis.NonLinearAds, p, nonLinearAds)
+	if !descend {
+		return keep
+	}
+	nonLinearAds.NonLinear = filter(nonLinearAds.NonLinear, func(i int, nonLinear *NonLinear) bool {
+		np := p.childAt("NonLinear", i)
+		keep, descend := visit(w, w.vis.NonLinear, np, nonLinear)
+		if descend {
+			w.url(np.child("IFrameResource"), URLIFrameResource, &nonLinear.IFrameResource)
+			w.url(np.child("NonLinearClickThrough"), URLNonLinearClickThrough, &nonLinear.NonLinearClickThrough)
+			if nonLinear.StaticResource != nil {
+				w.url(np.child("StaticResource"), URLStaticResource, &nonLinear.StaticResource.Data)
+			}
+			w.creativeExtensions(np.child("CreativeExtensions"), nonLinear.CreativeExtensions)
+		}
+		return keep
+	})
+	w.trackingEvents(p.child("TrackingEvents"), nonLinearAds.TrackingEvents)
+	return true
+}
+
+func (w *walker) extensions(p Path, exts *Extensions) {
+	if exts == nil {
+		return
+	}
+	exts.Extension = filter(exts.Extension, func(i int, ext *Extension) bool {
+		keep, _ := visit(w, w.vis.Extension, p.childAt("Extension", i), ext)
+		return keep
+	})
+}
+
+func (w *walker) creativeExtensions(p Path, exts *CreativeExtensions) {
+	if exts == nil {
+		return
+	}
+	exts.CreativeExtension = filter(exts.CreativeExtension, func(i int, ext *CreativeExtension) bool {
+		keep, _ := visit(w, w.vis.CreativeExtension, p.childAt("CreativeExtension", i), ext)
+		return keep
+	})
+}
@@ -0,0 +1,221 @@
+package vast2
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testWalkVAST() *VAST {
+	return &VAST{
+		Version: "2.0",
+		Ad: []Ad{
+			{
+				ID: "1",
+				InLine: &InLine{
+					Impression: []Impression{{Data: "http://imp.com"}},
+					Creatives: Creatives{Creative: []Creative{
+						{
+							ID: "c1",
+							Linear: &Linear{
+								TrackingEvents: &TrackingEvents{Tracking: []Tracking{
+									{Event: "start", Data: "http://start.com"},
+									{Event: "complete", Data: "http://complete.com"},
+								}},
+								MediaFiles: MediaFiles{MediaFile: []MediaFile{
+									{Type: "video/mp4", Data: "http://media.com/a.mp4"},
+									{Type: "video/x-flv", Data: "http://media.com/a.flv"},
+								}},
+							},
+						},
+						{
+							ID:           "c2",
+							CompanionAds: &CompanionAds{Companion: []Companion{{ID: "comp"}}},
+						},
+					}},
+					Extensions: &Extensions{Extension: []Extension{{Type: "pricing"}}},
+				},
+			},
+			{
+				ID:      "2",
+				Wrapper: &Wrapper{VASTAdTagURI: "http://tag.com"},
+			},
+		},
+	}
+}
+
+func TestPathString(t *testing.T) {
+	p := Path{{Name: "Ad", Index: 0}, {Name: "InLine", Index: -1}}
+	assert.Equal(t, p.String(), "Ad[0]/InLine")
+	assert.Equal(t, Path(nil).String(), "")
+}
+
+func TestWalkNil(t *testing.T) {
+	Walk(nil, Visitor{
+		Ad: func(p Path, ad *Ad) WalkAction {
+			t.Fatal("unexpected call")
+			return WalkContinue
+		},
+	})
+}
+
+func TestWalkPaths(t *testing.T) {
+	var paths []string
+	node := func(p Path) WalkAction {
+		paths = append(paths, p.String())
+		return WalkContinue
+	}
+	Walk(testWalkVAST(), Visitor{
+		Ad:           func(p Path, ad *Ad) WalkAction { return node(p) },
+		InLine:       func(p Path, inLine *InLine) WalkAction { return node(p) },
+		Wrapper:      func(p Path, wrapper *Wrapper) WalkAction { return node(p) },
+		Impression:   func(p Path, imp *Impression) WalkAction { return node(p) },
+		Creative:     func(p Path, creative *Creative) WalkAction { return node(p) },
+		Linear:       func(p Path, linear *Linear) WalkAction { return node(p) },
+		Tracking:     func(p Path, tracking *Tracking) WalkAction { return node(p) },
+		MediaFile:    func(p Path, mediaFile *MediaFile) WalkAction { return node(p) },
+		CompanionAds: func(p Path, companionAds *CompanionAds) WalkAction { return node(p) },
+		Companion:    func(p Path, companion *Companion) WalkAction { return node(p) },
+		Extension:    func(p Path, ext *Extension) WalkAction { return node(p) },
+		URL: func(p Path, kind URLKind, url *string) {
+			paths = append(paths, kind.String()+"@"+p.String())
+		},
+	})
+
+	assert.Equal(t, paths, []string{
+		"Ad[0]",
+		"Ad[0]/InLine",
+		"Ad[0]/InLine/Impression[0]",
+		"Impression@Ad[0]/InLine/Impression[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]",
+		"Tracking@Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[1]",
+		"Tracking@Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[1]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]",
+		"MediaFile@Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[1]",
+		"MediaFile@Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[1]",
+		"Ad[0]/InLine/Creatives/Creative[1]",
+		"Ad[0]/InLine/Creatives/Creative[1]/CompanionAds",
+		"Ad[0]/InLine/Creatives/Creative[1]/CompanionAds/Companion[0]",
+		"Ad[0]/InLine/Extensions/Extension[0]",
+		"Ad[1]",
+		"Ad[1]/Wrapper",
+		"VASTAdTagURI@Ad[1]/Wrapper/VASTAdTagURI",
+	})
+}
+
+func TestWalkRemove(t *testing.T) {
+	vast := testWalkVAST()
+	var seen []string
+	Walk(vast, Visitor{
+		MediaFile: func(p Path, mediaFile *MediaFile) WalkAction {
+			seen = append(seen, p.String())
+			if mediaFile.Type != "video/mp4" {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+		Tracking: func(p Path, tracking *Tracking) WalkAction {
+			if tracking.Event == "start" {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+		CompanionAds: func(p Path, companionAds *CompanionAds) WalkAction {
+			return WalkRemove
+		},
+		Ad: func(p Path, ad *Ad) WalkAction {
+			if ad.Wrapper != nil {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+	})
+
+	assert.Equal(t, len(vast.Ad), 1)
+	creatives := vast.Ad[0].InLine.Creatives.Creative
+	assert.Equal(t, creatives[0].Linear.MediaFiles.MediaFile, []MediaFile{{Type: "video/mp4", Data: "http://media.com/a.mp4"}})
+	assert.Equal(t, creatives[0].Linear.TrackingEvents.Tracking, []Tracking{{Event: "complete", Data: "http://complete.com"}})
+	assert.Nil(t, creatives[1].CompanionAds)
+	assert.Equal(t, seen, []string{
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[1]",
+	})
+
+	data, err := xml.Marshal(creatives[1])
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), `<Creative id="c2"></Creative>`)
+}
+
+func TestWalkSkip(t *testing.T) {
+	var urls []string
+	Walk(testWalkVAST(), Visitor{
+		Creative: func(p Path, creative *Creative) WalkAction {
+			return WalkSkip
+		},
+		URL: func(p Path, kind URLKind, url *string) {
+			urls = append(urls, *url)
+		},
+	})
+	assert.Equal(t, urls, []string{"http://imp.com", "http://tag.com"})
+}
+
+func TestWalkStop(t *testing.T) {
+	vast := testWalkVAST()
+	var urls []string
+	Walk(vast, Visitor{
+		Tracking: func(p Path, tracking *Tracking) WalkAction {
+			return WalkStop
+		},
+		MediaFile: func(p Path, mediaFile *MediaFile) WalkAction {
+			return WalkRemove
+		},
+		URL: func(p Path, kind URLKind, url *string) {
+			urls = append(urls, *url)
+		},
+	})
+	assert.Equal(t, urls, []string{"http://imp.com"})
+	assert.Equal(t, len(vast.Ad), 2)
+	assert.Equal(t, len(vast.Ad[0].InLine.Creatives.Creative[0].Linear.MediaFiles.MediaFile), 2)
+}
+
+func TestWalkCreativeExtensions(t *testing.T) {
+	exts := func(types ...string) *CreativeExtensions {
+		c := &CreativeExtensions{}
+		for _, typ := range types {
+			c.CreativeExtension = append(c.CreativeExtension, CreativeExtension{Type: typ, Data: []byte("<x/>")})
+		}
+		return c
+	}
+	vast := New(NewInLineAd("1", &InLine{Creatives: Creatives{Creative: []Creative{
+		NewLinearCreative(&Linear{CreativeExtensions: exts("moat", "keep")}),
+		NewCompanionAdsCreative(&CompanionAds{Companion: []Companion{{CreativeExtensions: exts("moat")}}}),
+		NewNonLinearAdsCreative(&NonLinearAds{NonLinear: []NonLinear{{CreativeExtensions: exts("keep", "moat")}}}),
+	}}}))
+
+	var paths []string
+	Walk(vast, Visitor{
+		CreativeExtension: func(p Path, ext *CreativeExtension) WalkAction {
+			paths = append(paths, p.String())
+			if ext.Type == "moat" {
+				return WalkRemove
+			}
+			return WalkContinue
+		},
+	})
+	assert.Equal(t, paths, []string{
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/CreativeExtensions/CreativeExtension[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/CreativeExtensions/CreativeExtension[1]",
+		"Ad[0]/InLine/Creatives/Creative[1]/CompanionAds/Companion[0]/CreativeExtensions/CreativeExtension[0]",
+		"Ad[0]/InLine/Creatives/Creative[2]/NonLinearAds/NonLinear[0]/CreativeExtensions/CreativeExtension[0]",
+		"Ad[0]/InLine/Creatives/Creative[2]/NonLinearAds/NonLinear[0]/CreativeExtensions/CreativeExtension[1]",
+	})
+	creatives := vast.Ad[0].InLine.Creatives.Creative
+	assert.Equal(t, creatives[0].Linear.CreativeExtensions, exts("keep"))
+	assert.Equal(t, creatives[1].CompanionAds.Companion[0].CreativeExtensions.CreativeExtension, []CreativeExtension{})
+	assert.Equal(t, creatives[2].NonLinearAds.NonLinear[0].CreativeExtensions, exts("keep"))
+}
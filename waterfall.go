@@ -0,0 +1,213 @@
+package vast2
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error codes substituted for the [ERRORCODE] macro of Error URLs. VAST 2.0
+// does not define codes, so these are the ones of later VAST versions which
+// players and ad servers commonly use.
+const (
+	ErrorCodeSchema         = 101
+	ErrorCodeTrafficking    = 200
+	ErrorCodeWrapper        = 300
+	ErrorCodeWrapperTimeout = 301
+	ErrorCodeWrapperLimit   = 302
+	ErrorCodeWrapperNoAd    = 303
+	ErrorCodeLinear         = 400
+	ErrorCodeMediaFile      = 403
+)
+
+// ErrorCodeMacro is replaced by the error code in the fired Error URLs.
+const ErrorCodeMacro = "[ERRORCODE]"
+
+// DefaultErrorTimeout bounds the requests for the Error URLs fired by a
+// Waterfall.
+const DefaultErrorTimeout = 2 * time.Second
+
+var (
+	ErrNoPlayableAd    = errors.New("vast2: no playable Ad")
+	ErrEmptyAd         = errors.New("vast2: Ad has neither InLine nor Wrapper")
+	ErrNoLinear        = errors.New("vast2: Ad has no Linear creative")
+	ErrInvalidDuration = errors.New("vast2: Linear has no valid Duration")
+	ErrNoMediaFile     = errors.New("vast2: Linear has no supported MediaFile")
+)
+
+// Rejection tells why an Ad of a waterfall was skipped.
+type Rejection struct {
+	// Index is the position of the Ad in the response.
+	Index int
+	AdID  string
+	// Code is the error code the Error URLs were fired with.
+	Code int
+	Err  error
+	// Hops are the fetches of the wrapper chain of the Ad, if any.
+	Hops []Hop
+}
+
+// Waterfall selects the first playable Ad of a response.
+type Waterfall struct {
+	// Resolver follows the wrapper chains and fires the Error URLs with
+	// its Client. A zero Resolver is used if nil.
+	Resolver *Resolver
+	// MIMETypes are the MediaFile types the player supports. If empty,
+	// any MediaFile which is not a VPAID unit is supported.
+	MIMETypes []string
+	// ErrorTimeout bounds the request for each fired Error URL,
+	// DefaultErrorTimeout if 0.
+	ErrorTimeout time.Duration
+
+	beacons sync.WaitGroup
+}
+
+func (w *Waterfall) resolver() *Resolver {
+	if w.Resolver != nil {
+		return w.Resolver
+	}
+	return &Resolver{}
+}
+
+// Select tries the Ads of v in order and returns the first playable one,
+// resolved to an InLine Ad, with the reasons the previous Ads were rejected
+// for. An Ad is playable if it has Linear creatives with a valid Duration
+// and a supported MediaFile. The Error URLs of every rejected Ad, and of the
+// wrappers leading to it, are fired with the matching error code in the
+// background, so that slow Error URLs do not delay the selection: use Wait
+// to wait for them. ErrNoPlayableAd is returned if no Ad is playable.
+func (w *Waterfall) Select(ctx context.Context, v *VAST) (*Ad, []Rejection, error) {
+	var rejections []Rejection
+	if v == nil {
+		return nil, nil, ErrNoPlayableAd
+	}
+	for i := range v.Ad {
+		ad, rejection := w.try(ctx, &v.Ad[i])
+		if rejection == nil {
+			return ad, rejections, nil
+		}
+		rejection.Index = i
+		rejection.AdID = v.Ad[i].ID
+		rejections = append(rejections, *rejection)
+	}
+	return nil, rejections, ErrNoPlayableAd
+}
+
+// try resolves ad and checks it is playable. The Error URLs are fired for
+// the returned Rejection, if any.
+func (w *Waterfall) try(ctx context.Context, ad *Ad) (*Ad, *Rejection) {
+	switch ad.Kind() {
+	case AdEmpty:
+		return nil, &Rejection{Code: ErrorCodeSchema, Err: ErrEmptyAd}
+	case AdAmbiguous:
+		w.fire(ctx, ad.InLine.Error, ErrorCodeSchema)
+		w.fire(ctx, ad.Wrapper.Error, ErrorCodeSchema)
+		return nil, &Rejection{Code: ErrorCodeSchema, Err: ErrAdChoice}
+	}
+
+	resolved, hops, err := w.resolver().ResolveAd(ctx, ad)
+	if err != nil {
+		code := wrapperErrorCode(err)
+		w.fire(ctx, ad.Wrapper.Error, code)
+		for _, hop := range hops {
+			if next := firstAd(hop.VAST); next != nil && next.Wrapper != nil {
+				w.fire(ctx, next.Wrapper.Error, code)
+			}
+		}
+		return nil, &Rejection{Code: code, Err: err, Hops: hops}
+	}
+
+	if code, err := w.check(resolved.InLine); err != nil {
+		w.fire(ctx, resolved.InLine.Error, code)
+		return nil, &Rejection{Code: code, Err: err, Hops: hops}
+	}
+	return resolved, nil
+}
+
+// check returns the error code and the reason inLine cannot be played.
+func (w *Waterfall) check(inLine *InLine) (int, error) {
+	linears := 0
+	for _, c := range inLine.Creatives.Creative {
+		if c.Kind() == CreativeAmbiguous {
+			return ErrorCodeSchema, ErrCreativeChoice
+		}
+		if c.Linear == nil {
+			continue
+		}
+		linears++
+		if d, err := ParseDuration(c.Linear.Duration); err != nil || d <= 0 {
+			return ErrorCodeSchema, ErrInvalidDuration
+		}
+		if !w.hasSupportedMediaFile(c.Linear) {
+			return ErrorCodeMediaFile, ErrNoMediaFile
+		}
+	}
+	if linears == 0 {
+		return ErrorCodeTrafficking, ErrNoLinear
+	}
+	return 0, nil
+}
+
+func (w *Waterfall) hasSupportedMediaFile(linear *Linear) bool {
+	for _, m := range linear.MediaFiles.MediaFile {
+		if strings.TrimSpace(m.Data) == "" {
+			continue
+		}
+		if len(w.MIMETypes) == 0 {
+			if !m.IsVPAID() {
+				return true
+			}
+			continue
+		}
+		for _, typ := range w.MIMETypes {
+			if strings.EqualFold(strings.TrimSpace(m.Type), typ) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func wrapperErrorCode(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrWrapperLimit):
+		return ErrorCodeWrapperLimit
+	case errors.Is(err, ErrNoAd):
+		return ErrorCodeWrapperNoAd
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorCodeWrapperTimeout
+	}
+	return ErrorCodeWrapper
+}
+
+// Wait waits for the requests of the Error URLs fired by Select.
+func (w *Waterfall) Wait() {
+	w.beacons.Wait()
+}
+
+// fire requests the non-empty urls with the ErrorCodeMacro replaced by
+// code, in the background. The requests outlive ctx, whose values they
+// keep, but not the ErrorTimeout. Failures are ignored: the waterfall goes
+// on regardless.
+func (w *Waterfall) fire(ctx context.Context, urls []string, code int) {
+	replaced := make([]string, len(urls))
+	for i, url := range urls {
+		replaced[i] = strings.ReplaceAll(url, ErrorCodeMacro, strconv.Itoa(code))
+	}
+	timeout := w.ErrorTimeout
+	if timeout <= 0 {
+		timeout = DefaultErrorTimeout
+	}
+	w.beacons.Add(1)
+	go func() {
+		defer w.beacons.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		w.resolver().Fire(ctx, replaced...)
+	}()
+}
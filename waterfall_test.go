@@ -0,0 +1,178 @@
+package vast2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// errorBeacons records the paths of the requests to /error/.
+type errorBeacons struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (b *errorBeacons) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	b.paths = append(b.paths, r.URL.Path)
+	b.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *errorBeacons) get() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.paths...)
+}
+
+func testWaterfallAd(id, errorURL string, mediaType string) Ad {
+	ad := testInLineAd()
+	ad.ID = id
+	ad.InLine.Error = []string{errorURL}
+	ad.InLine.Creatives.Creative[0].Linear.MediaFiles.MediaFile[0].Type = mediaType
+	return ad
+}
+
+func TestWaterfallSelect(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	beacons := &errorBeacons{}
+	mux.Handle("/error/", beacons)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	mux.HandleFunc("/empty", serveVAST(t, &VAST{Version: "2.0"}))
+
+	errorURL := func(name string) string {
+		return server.URL + "/error/" + name + "/" + ErrorCodeMacro
+	}
+	badDuration := testWaterfallAd("duration", errorURL("duration"), "video/mp4")
+	badDuration.InLine.Creatives.Creative[0].Linear.Duration = "15"
+	noLinear := testWaterfallAd("no-linear", errorURL("no-linear"), "video/mp4")
+	noLinear.InLine.Creatives.Creative[0] = NewCompanionAdsCreative(&CompanionAds{})
+	slow := testWrapperAd("slow", server.URL+"/slow")
+	slow.Wrapper.Error = []string{errorURL("slow")}
+	empty := testWrapperAd("empty", server.URL+"/empty")
+	empty.Wrapper.Error = []string{errorURL("empty")}
+
+	v := &VAST{Version: "2.0", Ad: []Ad{
+		{ID: "nothing"},
+		testWaterfallAd("webm", errorURL("webm"), "video/webm"),
+		testWaterfallAd("vpaid", errorURL("vpaid"), "application/javascript"),
+		badDuration,
+		noLinear,
+		slow,
+		empty,
+		testWaterfallAd("mp4", errorURL("mp4"), "video/mp4"),
+		testWaterfallAd("next", errorURL("next"), "video/mp4"),
+	}}
+	w := &Waterfall{
+		Resolver:  &Resolver{Client: &http.Client{Timeout: 50 * time.Millisecond}},
+		MIMETypes: []string{"video/mp4"},
+	}
+	ad, rejections, err := w.Select(context.Background(), v)
+	assert.Nil(t, err)
+	assert.Equal(t, ad.ID, "mp4")
+
+	var reasons []Rejection
+	for _, r := range rejections {
+		reasons = append(reasons, Rejection{Index: r.Index, AdID: r.AdID, Code: r.Code})
+	}
+	assert.Equal(t, reasons, []Rejection{
+		{Index: 0, AdID: "nothing", Code: ErrorCodeSchema},
+		{Index: 1, AdID: "webm", Code: ErrorCodeMediaFile},
+		{Index: 2, AdID: "vpaid", Code: ErrorCodeMediaFile},
+		{Index: 3, AdID: "duration", Code: ErrorCodeSchema},
+		{Index: 4, AdID: "no-linear", Code: ErrorCodeTrafficking},
+		{Index: 5, AdID: "slow", Code: ErrorCodeWrapperTimeout},
+		{Index: 6, AdID: "empty", Code: ErrorCodeWrapperNoAd},
+	})
+	assert.Equal(t, rejections[0].Err, ErrEmptyAd)
+	assert.Equal(t, rejections[1].Err, ErrNoMediaFile)
+	assert.Equal(t, rejections[3].Err, ErrInvalidDuration)
+	assert.Equal(t, rejections[4].Err, ErrNoLinear)
+	assert.Equal(t, len(rejections[6].Hops), 1)
+	assert.Equal(t, rejections[6].Err, ErrNoAd)
+
+	w.Wait()
+	paths := beacons.get()
+	sort.Strings(paths)
+	assert.Equal(t, paths, []string{
+		"/error/duration/101",
+		"/error/empty/303",
+		"/error/no-linear/200",
+		"/error/slow/301",
+		"/error/vpaid/403",
+		"/error/webm/403",
+	})
+}
+
+func TestWaterfallSlowErrorURL(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	beacons := &errorBeacons{}
+	mux.HandleFunc("/error/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.Handle("/error/", beacons)
+
+	v := New(
+		testWaterfallAd("slow", server.URL+"/error/slow", "video/webm"),
+		testWaterfallAd("mp4", server.URL+"/error/mp4", "video/mp4"),
+	)
+	w := &Waterfall{MIMETypes: []string{"video/mp4"}, ErrorTimeout: 100 * time.Millisecond}
+	start := time.Now()
+	ad, rejections, err := w.Select(context.Background(), v)
+	assert.Nil(t, err)
+	assert.Equal(t, ad.ID, "mp4")
+	assert.Len(t, rejections, 1)
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+
+	w.Wait()
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Empty(t, beacons.get())
+}
+
+func TestWaterfallDefaultMIMETypes(t *testing.T) {
+	v := &VAST{Version: "2.0", Ad: []Ad{
+		testWaterfallAd("vpaid", "", "application/x-shockwave-flash"),
+		testWaterfallAd("webm", "", "video/webm"),
+	}}
+	ad, rejections, err := (&Waterfall{}).Select(context.Background(), v)
+	assert.Nil(t, err)
+	assert.Equal(t, ad.ID, "webm")
+	assert.Equal(t, len(rejections), 1)
+
+	_, rejections, err = (&Waterfall{}).Select(context.Background(), &VAST{Ad: v.Ad[:1]})
+	assert.Equal(t, err, ErrNoPlayableAd)
+	assert.Equal(t, len(rejections), 1)
+
+	_, _, err = (&Waterfall{}).Select(context.Background(), nil)
+	assert.Equal(t, err, ErrNoPlayableAd)
+}
+
+func TestWaterfallResolvesWrapper(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/inline", serveVAST(t, &VAST{Version: "2.0", Ad: []Ad{testInLineAd()}}))
+
+	v := &VAST{Version: "2.0", Ad: []Ad{testWrapperAd("w", server.URL+"/inline")}}
+	ad, rejections, err := (&Waterfall{}).Select(context.Background(), v)
+	assert.Nil(t, err)
+	assert.Nil(t, rejections)
+	assert.Equal(t, ad.ID, "w")
+	assert.Equal(t, ad.Kind(), AdInLine)
+	assert.Equal(t, ad.InLine.Error, []string{"http://err.com/w"})
+}